@@ -0,0 +1,374 @@
+package game
+
+import (
+	"math/bits"
+	"sync"
+)
+
+// bitboard is the engine for 4x4 boards with tiles no larger than 2^15
+// Each square is a 4 bit exponent, so the whole board fits in a uint64.
+// Square (row, col) is at bit 4*(4*row+col), so each row is 16 bits with
+// column 0 in the lowest bits.
+// Moves are looked up a row at a time in precomputed tables.
+// It's a Bitboard that can be changed in place.
+type bitboard struct {
+	bits uint64
+}
+
+const (
+	bitboardSize     = 4
+	bitboardMaxPower = 15
+)
+
+var (
+	tablesOnce sync.Once
+
+	// results and scores of moving each possible row
+	rowLeft       [1 << 16]uint16
+	rowRight      [1 << 16]uint16
+	rowLeftScore  [1 << 16]uint64
+	rowRightScore [1 << 16]uint64
+)
+
+// fitsBitboard reports whether a bitboard can hold board without any
+// tile growing past maxPower
+func fitsBitboard(board [][]uint64, maxPower uint64) bool {
+	if maxPower > bitboardMaxPower || len(board) != bitboardSize {
+		return false
+	}
+
+	for _, row := range board {
+		if len(row) != bitboardSize {
+			return false
+		}
+
+		for _, val := range row {
+			if val > maxPower {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func newBitboard(board [][]uint64) *bitboard {
+	tablesOnce.Do(initTables)
+
+	e := &bitboard{}
+	for row := range board {
+		for col, val := range board[row] {
+			e.set(uint64(row), uint64(col), val)
+		}
+	}
+
+	return e
+}
+
+func initTables() {
+	for r := 0; r < 1<<16; r++ {
+		var line [bitboardSize]uint64
+		for i := range line {
+			line[i] = uint64(r>>(4*i)) & 0xf
+		}
+
+		moved, score := slideLine(line)
+
+		var result uint16
+		for i, val := range moved {
+			result |= uint16(val) << (4 * i)
+		}
+
+		rev := reverseRow(uint16(r))
+
+		rowLeft[r] = result
+		rowLeftScore[r] = score
+		rowRight[rev] = reverseRow(result)
+		rowRightScore[rev] = score
+	}
+}
+
+// slideLine moves a single line towards index 0, combining pairs
+// Two tiles of bitboardMaxPower are left alone rather than overflowing,
+// but that can't happen in a game since reaching the limit ends it
+func slideLine(line [bitboardSize]uint64) ([bitboardSize]uint64, uint64) {
+	var ret [bitboardSize]uint64
+	var score uint64
+	n := 0
+
+	// a combined tile can't combine again in the same move
+	merged := false
+
+	for _, val := range line {
+		if val == 0 {
+			continue
+		}
+
+		if n > 0 && !merged && ret[n-1] == val && val < bitboardMaxPower {
+			ret[n-1]++
+			score += uint64(1) << ret[n-1]
+			merged = true
+			continue
+		}
+
+		ret[n] = val
+		n++
+		merged = false
+	}
+
+	return ret, score
+}
+
+func reverseRow(r uint16) uint16 {
+	return r>>12 | (r>>4)&0x00f0 | (r<<4)&0x0f00 | r<<12
+}
+
+// transpose swaps rows and columns, so column moves can use the row tables
+func transpose(x uint64) uint64 {
+	a1 := x & 0xf0f00f0ff0f00f0f
+	a2 := x & 0x0000f0f00000f0f0
+	a3 := x & 0x0f0f00000f0f0000
+	a := a1 | (a2 << 12) | (a3 >> 12)
+
+	b1 := a & 0xff00ff0000ff00ff
+	b2 := a & 0x00ff00ff00000000
+	b3 := a & 0x00000000ff00ff00
+
+	return b1 | (b2 >> 24) | (b3 << 24)
+}
+
+// moveRows applies a row table to every row of bits
+func moveRows(bits uint64, table *[1 << 16]uint16, scores *[1 << 16]uint64) (uint64, uint64) {
+	var ret uint64
+	var score uint64
+
+	for row := uint(0); row < bitboardSize; row++ {
+		r := uint16(bits >> (16 * row))
+		ret |= uint64(table[r]) << (16 * row)
+		score += scores[r]
+	}
+
+	return ret, score
+}
+
+func (e *bitboard) get(row, col uint64) uint64 {
+	return Bitboard(e.bits).Get(row, col)
+}
+
+func (e *bitboard) set(row, col, val uint64) {
+	e.bits = uint64(Bitboard(e.bits).Set(row, col, val))
+}
+
+func (e *bitboard) move(dir Direction) (uint64, bool) {
+	next, score, moved := Bitboard(e.bits).Move(dir)
+	e.bits = uint64(next)
+
+	return score, moved
+}
+
+// Masks for working on every square at once
+const (
+	lowBits   = 0x1111111111111111 // lowest bit of each square
+	lowNibble = 0x0f0f0f0f0f0f0f0f // every other square, one to a byte
+	byteOnes  = 0x0101010101010101 // 1 in every byte
+	byteHigh  = 0x8080808080808080 // highest bit of every byte
+	byteFifth = 0x1010101010101010 // bit 4 of every byte
+)
+
+// filled has the lowest bit of each square set when the square isn't empty
+func (b Bitboard) filled() uint64 {
+	x := uint64(b)
+	x |= x >> 2
+	x |= x >> 1
+
+	return x & lowBits
+}
+
+// Empties counts the empty squares
+func (b Bitboard) Empties() uint64 {
+	return bitboardSize*bitboardSize - uint64(bits.OnesCount64(b.filled()))
+}
+
+func (e *bitboard) empties() uint64 {
+	return Bitboard(e.bits).Empties()
+}
+
+func (e *bitboard) emptyAt(n uint64) (uint64, uint64) {
+	empty := ^Bitboard(e.bits).filled() & lowBits
+
+	// each byte has up to two empty squares, sum them up to each byte
+	counts := (empty + empty>>4) & lowNibble
+	upTo := counts * byteOnes
+
+	// the n-th empty square is in the first byte whose sum passes n
+	// No byte borrows from the next, since the sums are at most 16.
+	past := ((upTo | byteHigh) - (n+1)*byteOnes) & byteHigh
+	if past == 0 {
+		panic("not enough empty squares")
+	}
+
+	b := uint(bits.TrailingZeros64(past)) / 8
+	before := (upTo << 8 >> (8 * b)) & 0xff
+
+	// it's either the lower or upper square of that byte
+	i := uint64(2 * b)
+	if n > before || (empty>>(8*b))&1 == 0 {
+		i++
+	}
+
+	return i / bitboardSize, i % bitboardSize
+}
+
+func (e *bitboard) won(limit uint64) bool {
+	if limit > bitboardMaxPower {
+		return false
+	}
+
+	// adding 16-limit to a square carries into bit 4 when it's at least limit
+	// Splitting the squares a byte each keeps the carries apart.
+	add := (16 - limit) * byteOnes
+	even := e.bits&lowNibble + add
+	odd := (e.bits>>4)&lowNibble + add
+
+	return (even|odd)&byteFifth != 0
+}
+
+func (e *bitboard) lost() bool {
+	return Bitboard(e.bits).Lost()
+}
+
+func (e *bitboard) board() [][]uint64 {
+	return Bitboard(e.bits).Board()
+}
+
+func (e *bitboard) clone() engine {
+	clone := *e
+	return &clone
+}
+
+// Bitboard is a 4x4 board packed into a uint64, the way games keep them,
+// for players that search through many boards without allocating
+// Each square is a 4 bit power of 2 like the values in Game.Board, so
+// tiles can't grow past 2^15.
+type Bitboard uint64
+
+// NewBitboard packs board into a Bitboard
+// Returns false unless board is 4x4 with every tile below 2^15, leaving
+// room for tiles to combine.
+func NewBitboard(board [][]uint64) (Bitboard, bool) {
+	if !fitsBitboard(board, bitboardMaxPower-1) {
+		return 0, false
+	}
+
+	var b Bitboard
+	for row := range board {
+		for col, val := range board[row] {
+			b = b.Set(uint64(row), uint64(col), val)
+		}
+	}
+
+	return b, true
+}
+
+// Get returns the value of a square
+func (b Bitboard) Get(row, col uint64) uint64 {
+	return uint64(b>>(4*(bitboardSize*row+col))) & 0xf
+}
+
+// Set returns b with a square changed to val
+func (b Bitboard) Set(row, col, val uint64) Bitboard {
+	shift := 4 * (bitboardSize*row + col)
+	return b&^(0xf<<shift) | Bitboard(val&0xf)<<shift
+}
+
+// Move returns b with its tiles moved and combined in the direction
+// specified, without placing any new tiles
+// Returns the score gained and whether anything moved
+func (b Bitboard) Move(dir Direction) (Bitboard, uint64, bool) {
+	tablesOnce.Do(initTables)
+
+	bits := uint64(b)
+
+	var next uint64
+	var score uint64
+
+	switch dir {
+	case DirLeft:
+		next, score = moveRows(bits, &rowLeft, &rowLeftScore)
+
+	case DirRight:
+		next, score = moveRows(bits, &rowRight, &rowRightScore)
+
+	case DirUp:
+		next, score = moveRows(transpose(bits), &rowLeft, &rowLeftScore)
+		next = transpose(next)
+
+	case DirDown:
+		next, score = moveRows(transpose(bits), &rowRight, &rowRightScore)
+		next = transpose(next)
+
+	default:
+		return b, 0, false
+	}
+
+	return Bitboard(next), score, next != bits
+}
+
+// Spawns calls fn with every board a new tile could make and the chance
+// of it, which add up to 1 unless the board is full
+func (b Bitboard) Spawns(fn func(next Bitboard, prob float64)) {
+	empties := b.Empties()
+	if empties == 0 {
+		return
+	}
+
+	// worked out like SpawnOutcomes does, to give exactly the same chances
+	prob := 1 / float64(empties)
+	two := prob * (1 - probFour)
+	four := prob * probFour
+
+	// one bit for each empty square
+	for empty := ^b.filled() & lowBits; empty != 0; empty &= empty - 1 {
+		shift := uint(bits.TrailingZeros64(empty))
+		fn(b|spawnTwo<<shift, two)
+		fn(b|spawnFour<<shift, four)
+	}
+}
+
+// Lost reports whether no move can change the board
+func (b Bitboard) Lost() bool {
+	if b.Empties() > 0 {
+		return false
+	}
+
+	tablesOnce.Do(initTables)
+
+	// on a full board, left moves nothing exactly when right moves nothing
+	bits := uint64(b)
+	left, _ := moveRows(bits, &rowLeft, &rowLeftScore)
+	t := transpose(bits)
+	up, _ := moveRows(t, &rowLeft, &rowLeftScore)
+
+	return left == bits && up == t
+}
+
+// Fill copies b into board, which must be 4x4
+func (b Bitboard) Fill(board [][]uint64) {
+	for row := range board {
+		for col := range board[row] {
+			board[row][col] = b.Get(uint64(row), uint64(col))
+		}
+	}
+}
+
+// Board returns b as a new [][]uint64, like Game.Board
+func (b Bitboard) Board() [][]uint64 {
+	ret := make([][]uint64, bitboardSize)
+	for row := range ret {
+		ret[row] = make([]uint64, bitboardSize)
+	}
+
+	b.Fill(ret)
+
+	return ret
+}
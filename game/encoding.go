@@ -0,0 +1,201 @@
+package game
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/dystopium/2048/internal/binio"
+)
+
+// saveVersion is the version of the saved game formats
+const saveVersion = 1
+
+// saveMagic starts every binary saved game
+const saveMagic = "2048sav"
+
+// ErrBadSave is returned when unmarshalling something that isn't a valid saved game
+var ErrBadSave = errors.New("game: invalid saved game")
+
+// saved is everything that's kept when saving a game
+// The undo history isn't kept.
+type saved struct {
+	Version uint64     `json:"version"`
+	Width   uint64     `json:"width"`
+	Height  uint64     `json:"height"`
+	Limit   uint64     `json:"limit"`
+	Adds    uint64     `json:"adds"`
+	Seed    int64      `json:"seed"`
+	RNG     uint64     `json:"rng"`
+	Score   uint64     `json:"score"`
+	Moves   uint64     `json:"moves"`
+	State   string     `json:"state"`
+	Board   [][]uint64 `json:"board"`
+}
+
+func (g *Game) save() saved {
+	return saved{
+		Version: saveVersion,
+		Width:   g.width,
+		Height:  g.height,
+		Limit:   g.limit,
+		Adds:    g.adds,
+		Seed:    g.seed,
+		RNG:     g.src.state,
+		Score:   g.score,
+		Moves:   g.moves,
+		State:   g.state.String(),
+		Board:   g.Board(),
+	}
+}
+
+// load replaces the game with s, keeping the observers, history limit and
+// tile tracking
+func (g *Game) load(s saved) error {
+	if s.Version != saveVersion {
+		return fmt.Errorf("game: unsupported saved game version %v", s.Version)
+	}
+
+	if s.Width == 0 || s.Height == 0 || uint64(len(s.Board)) != s.Height {
+		return ErrBadSave
+	}
+
+	for _, row := range s.Board {
+		if uint64(len(row)) != s.Width {
+			return ErrBadSave
+		}
+	}
+
+	state, err := parseState(s.State)
+	if err != nil {
+		return err
+	}
+
+	loaded := newGame(s.Board, s.Limit, s.Adds, s.Seed)
+	loaded.src.state = s.RNG
+	loaded.score = s.Score
+	loaded.moves = s.Moves
+	loaded.state = state
+	loaded.observers = g.observers
+	loaded.historyLimit = g.historyLimit
+
+	if g.ids != nil {
+		// carry on numbering, so loaded tiles can't be mistaken for old ones
+		loaded.nextID = g.nextID
+		loaded.TrackTiles(true)
+	}
+
+	*g = *loaded
+
+	for _, o := range g.observers {
+		o.Loaded()
+	}
+
+	return nil
+}
+
+func parseState(s string) (State, error) {
+	for _, state := range []State{StatePlaying, StateWon, StateLost} {
+		if s == state.String() {
+			return state, nil
+		}
+	}
+
+	return stateInvalid, fmt.Errorf("game: unknown state %q", s)
+}
+
+// MarshalJSON saves the game as JSON, including the position of its random
+// number generator so a loaded game places the same tiles
+// The undo history isn't saved.
+func (g *Game) MarshalJSON() ([]byte, error) {
+	return json.Marshal(g.save())
+}
+
+// UnmarshalJSON replaces the game with one saved by MarshalJSON
+// The history limit and tile tracking carry on, but there's nothing to undo
+// and the loaded tiles get new IDs.
+func (g *Game) UnmarshalJSON(data []byte) error {
+	var s saved
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	return g.load(s)
+}
+
+// MarshalBinary saves the game in a compact binary format, including the
+// position of its random number generator so a loaded game places the same tiles
+// After the magic string every number is a varint. The undo history isn't saved.
+func (g *Game) MarshalBinary() ([]byte, error) {
+	s := g.save()
+	buf := &bytes.Buffer{}
+	w := binio.NewWriter(buf)
+
+	w.String(saveMagic)
+	w.Uvarint(s.Version)
+	w.Uvarint(s.Width)
+	w.Uvarint(s.Height)
+	w.Uvarint(s.Limit)
+	w.Uvarint(s.Adds)
+	w.Varint(s.Seed)
+	w.Uvarint(s.RNG)
+	w.Uvarint(s.Score)
+	w.Uvarint(s.Moves)
+	w.Byte(byte(g.state))
+
+	for _, row := range s.Board {
+		for _, val := range row {
+			w.Uvarint(val)
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary replaces the game with one saved by MarshalBinary
+// The history limit and tile tracking carry on, but there's nothing to undo
+// and the loaded tiles get new IDs.
+func (g *Game) UnmarshalBinary(data []byte) error {
+	r := binio.NewReader(bytes.NewReader(data))
+	if !r.Magic(saveMagic) {
+		return ErrBadSave
+	}
+
+	var s saved
+	s.Version = r.Uvarint()
+	s.Width = r.Uvarint()
+	s.Height = r.Uvarint()
+	s.Limit = r.Uvarint()
+	s.Adds = r.Uvarint()
+	s.Seed = r.Varint()
+	s.RNG = r.Uvarint()
+	s.Score = r.Uvarint()
+	s.Moves = r.Uvarint()
+	s.State = State(r.Byte()).String()
+
+	// a varint is at least a byte, so a valid board can't be larger than what's left
+	left := uint64(len(data)) - r.Count()
+	if r.Err() == nil && (s.Width > left || s.Height > left || s.Width*s.Height > left) {
+		return ErrBadSave
+	}
+
+	for row := uint64(0); row < s.Height && r.Err() == nil; row++ {
+		vals := make([]uint64, s.Width)
+		for col := range vals {
+			vals[col] = r.Uvarint()
+		}
+
+		s.Board = append(s.Board, vals)
+	}
+
+	if r.Err() != nil {
+		return ErrBadSave
+	}
+
+	return g.load(s)
+}
@@ -0,0 +1,205 @@
+package game
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/dystopium/2048/internal/binio"
+)
+
+// encodings saves and loads games in each format
+var encodings = map[string]struct {
+	marshal   func(g *Game) ([]byte, error)
+	unmarshal func(g *Game, data []byte) error
+}{
+	"binary": {(*Game).MarshalBinary, (*Game).UnmarshalBinary},
+	"json":   {(*Game).MarshalJSON, (*Game).UnmarshalJSON},
+}
+
+// played is a game part way through, on a board the bitboard can't hold
+func played() *Game {
+	g := NewSeededGame(5, 3, 11, 2, -9)
+	for i := 0; i < 12; i++ {
+		g.Move(Directions[i%len(Directions)])
+	}
+
+	return g
+}
+
+// sameGame reports whether a and b are in the same position
+func sameGame(a, b *Game) bool {
+	return reflect.DeepEqual(a.Board(), b.Board()) && a.Score() == b.Score() && a.TotalMoves() == b.TotalMoves() && a.State() == b.State()
+}
+
+func TestSaveRoundTrip(t *testing.T) {
+	for name, enc := range encodings {
+		g := played()
+
+		data, err := enc.marshal(g)
+		if err != nil {
+			t.Fatalf("%v: %v", name, err)
+		}
+
+		loaded := NewSeededGame(4, 4, 11, 1, 1)
+		if err := enc.unmarshal(loaded, data); err != nil {
+			t.Fatalf("%v: %v", name, err)
+		}
+
+		if !sameGame(loaded, g) {
+			t.Fatalf("%v: loaded\n%v\nwant\n%v", name, loaded, g)
+		}
+
+		// the loaded game has to place the same tiles from here on
+		for i := 0; i < 200 && g.State() == StatePlaying; i++ {
+			dir := Directions[(i*7/3)%len(Directions)]
+			g.Move(dir)
+			loaded.Move(dir)
+
+			if !sameGame(loaded, g) {
+				t.Fatalf("%v: after %v more moves loaded game is at\n%v\nwant\n%v", name, i+1, loaded, g)
+			}
+		}
+	}
+}
+
+// binarySave writes the fields of a binary save before the board, and
+// whatever board is given
+func binarySave(t *testing.T, width, height uint64, state State, board ...uint64) []byte {
+	t.Helper()
+
+	buf := &bytes.Buffer{}
+	w := binio.NewWriter(buf)
+
+	w.String(saveMagic)
+	w.Uvarint(saveVersion)
+	w.Uvarint(width)
+	w.Uvarint(height)
+	w.Uvarint(11)
+	w.Uvarint(2)
+	w.Varint(-3)
+	w.Uvarint(12345)
+	w.Uvarint(100)
+	w.Uvarint(10)
+	w.Byte(byte(state))
+
+	for _, val := range board {
+		w.Uvarint(val)
+	}
+
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestSaveCorrupt(t *testing.T) {
+	small := binarySave(t, 2, 1, StatePlaying, 1, 2)
+	if err := NewSeededGame(4, 4, 11, 1, 1).UnmarshalBinary(small); err != nil {
+		t.Fatalf("2x1 board: %v", err)
+	}
+
+	for name, data := range map[string][]byte{
+		"missing square":   binarySave(t, 2, 1, StatePlaying, 1),
+		"empty board":      binarySave(t, 0, 0, StatePlaying),
+		"unknown state":    binarySave(t, 2, 1, State(9), 1, 2),
+		"oversized board":  binarySave(t, 1<<40, 1<<40, StatePlaying, 1, 2),
+		"overflowing area": binarySave(t, 1<<33, 1<<33, StatePlaying, 1, 2),
+		"json save":        mustJSON(t, played()),
+	} {
+		if err := NewSeededGame(4, 4, 11, 1, 1).UnmarshalBinary(data); err == nil {
+			t.Errorf("%v: got no error", name)
+		}
+	}
+
+	var s saved
+	if err := json.Unmarshal(mustJSON(t, played()), &s); err != nil {
+		t.Fatal(err)
+	}
+
+	s.Board = s.Board[1:]
+	short, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := NewSeededGame(4, 4, 11, 1, 1).UnmarshalJSON(short); err != ErrBadSave {
+		t.Errorf("missing row: got error %v, want %v", err, ErrBadSave)
+	}
+
+	good := string(mustJSON(t, played()))
+	badState := strings.Replace(good, `"state":"Playing"`, `"state":"Paused"`, 1)
+	if badState == good {
+		t.Fatal("no state to corrupt")
+	}
+
+	if err := NewSeededGame(4, 4, 11, 1, 1).UnmarshalJSON([]byte(badState)); err == nil {
+		t.Error("unknown state: got no error")
+	}
+}
+
+func mustJSON(t *testing.T, g *Game) []byte {
+	t.Helper()
+
+	data, err := g.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return data
+}
+
+// TestLoadKeepsSettings checks loading doesn't turn off history or tile tracking
+func TestLoadKeepsSettings(t *testing.T) {
+	for name, enc := range encodings {
+		data, err := enc.marshal(played())
+		if err != nil {
+			t.Fatalf("%v: %v", name, err)
+		}
+
+		g := NewSeededGame(4, 4, 11, 1, 1)
+		g.SetHistory(5)
+		g.TrackTiles(true)
+		g.Move(DirLeft)
+		g.Move(DirUp)
+
+		if err := enc.unmarshal(g, data); err != nil {
+			t.Fatalf("%v: %v", name, err)
+		}
+
+		if g.CanUndo() || g.CanRedo() {
+			t.Errorf("%v: moves from before loading can still be undone or redone", name)
+		}
+
+		if len(g.TileEvents()) != 0 {
+			t.Errorf("%v: tile events from before loading: %v", name, g.TileEvents())
+		}
+
+		before := g.Board()
+
+		for _, dir := range Directions {
+			if _, err := g.Play(dir); err != nil {
+				t.Fatalf("%v: %v", name, err)
+			}
+
+			if len(g.TileEvents()) > 0 {
+				break
+			}
+		}
+
+		if len(g.TileEvents()) == 0 {
+			t.Errorf("%v: no tile events after loading", name)
+		}
+
+		if !g.Undo() {
+			t.Fatalf("%v: can't undo after loading", name)
+		}
+
+		if !reflect.DeepEqual(g.Board(), before) {
+			t.Errorf("%v: undoing gave\n%v\nwant\n%v", name, g.Board(), before)
+		}
+	}
+}
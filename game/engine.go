@@ -0,0 +1,334 @@
+package game
+
+// engine stores the board and applies moves to it
+// Every engine holds the *exponent* of the value, not the value itself.
+// 2 is represented as 1, 4 as 2, etc.
+type engine interface {
+	get(row, col uint64) uint64
+	set(row, col, val uint64)
+
+	// move slides and combines tiles in the direction given
+	// Returns the added score and whether anything moved
+	move(dir Direction) (uint64, bool)
+
+	// empties reports how many squares are empty
+	empties() uint64
+
+	// emptyAt finds the nth empty square, for n less than empties
+	emptyAt(n uint64) (row, col uint64)
+
+	// won reports whether any tile has reached the limit
+	won(limit uint64) bool
+	lost() bool
+
+	// board returns a copy of the board, row major
+	board() [][]uint64
+	clone() engine
+}
+
+// newEngine picks the fastest engine that can hold board without
+// any tile growing past maxPower
+func newEngine(board [][]uint64, maxPower uint64) engine {
+	if fitsBitboard(board, maxPower) {
+		return newBitboard(board)
+	}
+
+	return newGrid(board)
+}
+
+// grid is the generic engine that handles any board size and tile value
+// This allows much higher target values, up to 2^(2^64)
+// Moves reuse the same scratch space, so they don't allocate.
+type grid struct {
+	width  uint64
+	height uint64
+
+	// cells is row major, square (row, col) is at row*width+col
+	cells []uint64
+
+	// line is scratch space for the tiles of one row or column
+	line []uint64
+
+	// empty is a Fenwick tree counting empty squares, so the nth empty
+	// square in row major order can be found without scanning the board.
+	// Going by position rather than by the order squares emptied means a
+	// saved and loaded game places the same tiles.
+	// empty[i] covers the squares up to i-1, so empty[0] is unused.
+	empty    []uint64
+	numEmpty uint64
+
+	// step is the largest power of 2 no bigger than the number of squares
+	step uint64
+
+	// tiles never shrink, so the largest is only ever replaced by a larger one
+	largest uint64
+}
+
+func newGrid(board [][]uint64) *grid {
+	height := uint64(len(board))
+	width := uint64(len(board[0]))
+	size := width * height
+
+	line := width
+	if height > line {
+		line = height
+	}
+
+	e := &grid{
+		width:  width,
+		height: height,
+		cells:  make([]uint64, size),
+		line:   make([]uint64, line),
+		empty:  make([]uint64, size+1),
+		step:   1,
+	}
+
+	// every square starts out empty
+	for i := uint64(1); i <= size; i++ {
+		e.empty[i] = i & -i
+	}
+	e.numEmpty = size
+
+	for e.step*2 <= size {
+		e.step *= 2
+	}
+
+	for row := range board {
+		for col, val := range board[row] {
+			e.set(uint64(row), uint64(col), val)
+		}
+	}
+
+	return e
+}
+
+func (e *grid) get(row, col uint64) uint64 {
+	return e.cells[row*e.width+col]
+}
+
+func (e *grid) set(row, col, val uint64) {
+	sq := row*e.width + col
+	old := e.cells[sq]
+	e.cells[sq] = val
+
+	if val > e.largest {
+		e.largest = val
+	}
+
+	switch {
+	case old == 0 && val != 0:
+		e.numEmpty--
+		for i := sq + 1; i < uint64(len(e.empty)); i += i & -i {
+			e.empty[i]--
+		}
+
+	case old != 0 && val == 0:
+		e.numEmpty++
+		for i := sq + 1; i < uint64(len(e.empty)); i += i & -i {
+			e.empty[i]++
+		}
+	}
+}
+
+// lines finds how many rows or columns a move in dir slides, and how long they are
+// Returns false for an invalid direction
+func lines(dir Direction, width, height uint64) (count, length uint64, ok bool) {
+	switch dir {
+	case DirUp, DirDown:
+		return width, height, true
+	case DirLeft, DirRight:
+		return height, width, true
+	default:
+		return 0, 0, false
+	}
+}
+
+// lineSquare finds the square i steps along line number l, starting from the
+// edge that dir moves tiles towards
+func lineSquare(dir Direction, width, height, l, i uint64) (row, col uint64) {
+	switch dir {
+	case DirUp:
+		return i, l
+	case DirDown:
+		return height - 1 - i, l
+	case DirLeft:
+		return l, i
+	default:
+		return l, width - 1 - i
+	}
+}
+
+// tileMove is where one tile goes in a move
+type tileMove struct {
+	from  Square
+	to    Square
+	value uint64
+
+	// merged is set for the second tile of a pair, which combines with
+	// the tile moved to the same square just before it
+	merged bool
+}
+
+// planMove lists where every tile on e goes when moving in dir, line by line
+// Follows the same rules as the engines, each pair combines once starting
+// from the edge tiles move towards.
+func planMove(e engine, dir Direction, width, height uint64) []tileMove {
+	count, length, ok := lines(dir, width, height)
+	if !ok {
+		return nil
+	}
+
+	var plan []tileMove
+
+	for l := uint64(0); l < count; l++ {
+		var dest uint64
+
+		// the tile moved to dest-1, if it can still combine
+		var prev uint64
+
+		for i := uint64(0); i < length; i++ {
+			row, col := lineSquare(dir, width, height, l, i)
+			val := e.get(row, col)
+			if val == 0 {
+				continue
+			}
+
+			tm := tileMove{from: Square{row, col}, value: val}
+
+			if prev == val {
+				tm.merged = true
+				prev = 0
+			} else {
+				prev = val
+				dest++
+			}
+
+			row, col = lineSquare(dir, width, height, l, dest-1)
+			tm.to = Square{row, col}
+
+			plan = append(plan, tm)
+		}
+	}
+
+	return plan
+}
+
+func (e *grid) square(dir Direction, l, i uint64) (row, col uint64) {
+	return lineSquare(dir, e.width, e.height, l, i)
+}
+
+func (e *grid) move(dir Direction) (uint64, bool) {
+	count, length, ok := lines(dir, e.width, e.height)
+	if !ok {
+		return 0, false
+	}
+
+	var score uint64
+	moved := false
+
+	for l := uint64(0); l < count; l++ {
+		// gather the tiles, combining pairs as they're found
+		n := 0
+
+		// a combined tile can't combine again in the same move
+		merged := false
+
+		for i := uint64(0); i < length; i++ {
+			val := e.get(e.square(dir, l, i))
+			if val == 0 {
+				continue
+			}
+
+			if n > 0 && !merged && e.line[n-1] == val {
+				e.line[n-1]++
+				score += uint64(1) << e.line[n-1]
+				merged = true
+				continue
+			}
+
+			e.line[n] = val
+			n++
+			merged = false
+		}
+
+		// write them back, padded out with empty squares
+		for i := uint64(0); i < length; i++ {
+			var val uint64
+			if i < uint64(n) {
+				val = e.line[i]
+			}
+
+			row, col := e.square(dir, l, i)
+			if e.get(row, col) != val {
+				e.set(row, col, val)
+				moved = true
+			}
+		}
+	}
+
+	return score, moved
+}
+
+func (e *grid) empties() uint64 {
+	return e.numEmpty
+}
+
+func (e *grid) emptyAt(n uint64) (uint64, uint64) {
+	// find the last square with at most n empty squares before it
+	var sq uint64
+	for step := e.step; step > 0; step /= 2 {
+		if next := sq + step; next < uint64(len(e.empty)) && e.empty[next] <= n {
+			sq = next
+			n -= e.empty[next]
+		}
+	}
+
+	return sq / e.width, sq % e.width
+}
+
+func (e *grid) won(limit uint64) bool {
+	return e.largest >= limit
+}
+
+func (e *grid) lost() bool {
+	// blank space means there's still a move
+	if e.numEmpty > 0 {
+		return false
+	}
+
+	for row := uint64(0); row < e.height; row++ {
+		for col := uint64(0); col < e.width; col++ {
+			val := e.get(row, col)
+
+			if row < e.height-1 && val == e.get(row+1, col) {
+				return false
+			}
+
+			if col < e.width-1 && val == e.get(row, col+1) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func (e *grid) board() [][]uint64 {
+	ret := make([][]uint64, e.height)
+	for row := range ret {
+		start := uint64(row) * e.width
+		ret[row] = make([]uint64, e.width)
+		copy(ret[row], e.cells[start:start+e.width])
+	}
+
+	return ret
+}
+
+func (e *grid) clone() engine {
+	clone := *e
+	clone.cells = append([]uint64(nil), e.cells...)
+	clone.line = make([]uint64, len(e.line))
+	clone.empty = append([]uint64(nil), e.empty...)
+
+	return &clone
+}
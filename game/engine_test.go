@@ -0,0 +1,484 @@
+package game
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// engines creates every engine that can hold board
+func engines(board [][]uint64) map[string]engine {
+	ret := map[string]engine{"grid": newGrid(board)}
+
+	if fitsBitboard(board, bitboardMaxPower-1) {
+		ret["bitboard"] = newBitboard(board)
+	}
+
+	return ret
+}
+
+// refMove is the plain rules of a move, written as simply as possible
+// to check the engines against
+func refMove(board [][]uint64, dir Direction) ([][]uint64, uint64) {
+	height := uint64(len(board))
+	width := uint64(len(board[0]))
+
+	ret := make([][]uint64, height)
+	for i := range ret {
+		ret[i] = make([]uint64, width)
+	}
+
+	count, length, _ := lines(dir, width, height)
+	var score uint64
+
+	for l := uint64(0); l < count; l++ {
+		var tiles []uint64
+		for i := uint64(0); i < length; i++ {
+			row, col := lineSquare(dir, width, height, l, i)
+			if board[row][col] != 0 {
+				tiles = append(tiles, board[row][col])
+			}
+		}
+
+		var out []uint64
+		for i := 0; i < len(tiles); i++ {
+			if i+1 < len(tiles) && tiles[i] == tiles[i+1] {
+				out = append(out, tiles[i]+1)
+				score += 1 << (tiles[i] + 1)
+				i++
+				continue
+			}
+
+			out = append(out, tiles[i])
+		}
+
+		for i, val := range out {
+			row, col := lineSquare(dir, width, height, l, uint64(i))
+			ret[row][col] = val
+		}
+	}
+
+	return ret, score
+}
+
+// refLost reports whether board has no empty squares and no neighbours
+// that could combine
+func refLost(board [][]uint64) bool {
+	for row := range board {
+		for col, val := range board[row] {
+			if val == 0 {
+				return false
+			}
+
+			if row+1 < len(board) && board[row+1][col] == val {
+				return false
+			}
+
+			if col+1 < len(board[row]) && board[row][col+1] == val {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// refEmpties lists the empty squares of board in row major order
+func refEmpties(board [][]uint64) []Square {
+	var ret []Square
+	for row := range board {
+		for col, val := range board[row] {
+			if val == 0 {
+				ret = append(ret, Square{Row: uint64(row), Col: uint64(col)})
+			}
+		}
+	}
+
+	return ret
+}
+
+// checkEngine compares e, holding board, with the reference rules
+func checkEngine(t *testing.T, name string, e engine, board [][]uint64) {
+	t.Helper()
+
+	if got, want := e.lost(), refLost(board); got != want {
+		t.Fatalf("%v: lost() = %v, want %v on %v", name, got, want, board)
+	}
+
+	empties := refEmpties(board)
+	if got := e.empties(); got != uint64(len(empties)) {
+		t.Fatalf("%v: empties() = %v, want %v on %v", name, got, len(empties), board)
+	}
+
+	for n, want := range empties {
+		if row, col := e.emptyAt(uint64(n)); row != want.Row || col != want.Col {
+			t.Fatalf("%v: emptyAt(%v) = (%v, %v), want (%v, %v) on %v", name, n, row, col, want.Row, want.Col, board)
+		}
+	}
+
+	var max uint64
+	for _, row := range board {
+		for _, val := range row {
+			if val > max {
+				max = val
+			}
+		}
+	}
+
+	for limit := uint64(0); limit <= bitboardMaxPower+1; limit++ {
+		if got := e.won(limit); got != (max >= limit) {
+			t.Fatalf("%v: won(%v) = %v, want %v on %v", name, limit, got, max >= limit, board)
+		}
+	}
+
+	for _, dir := range Directions {
+		moved := e.clone()
+		score, ok := moved.move(dir)
+
+		want, wantScore := refMove(board, dir)
+		wantMoved := !reflect.DeepEqual(want, board)
+
+		if got := moved.board(); !reflect.DeepEqual(got, want) || score != wantScore || ok != wantMoved {
+			t.Fatalf("%v: moving %v gave %v, %v, %v, want %v, %v, %v on %v", name, dir, got, score, ok, want, wantScore, wantMoved, board)
+		}
+
+		// planning the move has to agree with making it
+		planned := make([][]uint64, len(board))
+		for i := range planned {
+			planned[i] = make([]uint64, len(board[i]))
+		}
+
+		for _, tm := range planMove(e, dir, uint64(len(board[0])), uint64(len(board))) {
+			if tm.merged {
+				planned[tm.to.Row][tm.to.Col]++
+			} else {
+				planned[tm.to.Row][tm.to.Col] = tm.value
+			}
+		}
+
+		if !reflect.DeepEqual(planned, want) {
+			t.Fatalf("%v: planning %v gave %v, want %v on %v", name, dir, planned, want, board)
+		}
+
+		// the engine has to stay consistent after moving too
+		if got := moved.empties(); got != uint64(len(refEmpties(want))) {
+			t.Fatalf("%v: empties() after moving %v = %v, want %v", name, dir, got, len(refEmpties(want)))
+		}
+	}
+}
+
+func TestEngineMoves(t *testing.T) {
+	tests := []struct {
+		name  string
+		board [][]uint64
+		dir   Direction
+		want  [][]uint64
+		score uint64
+	}{
+		{
+			name:  "pairs combine once",
+			board: [][]uint64{{1, 1, 1, 1}, {0, 0, 0, 0}, {0, 0, 0, 0}, {0, 0, 0, 0}},
+			dir:   DirLeft,
+			want:  [][]uint64{{2, 2, 0, 0}, {0, 0, 0, 0}, {0, 0, 0, 0}, {0, 0, 0, 0}},
+			score: 8,
+		},
+		{
+			name:  "combined tiles don't combine again",
+			board: [][]uint64{{1, 1, 2, 0}, {0, 0, 0, 0}, {0, 0, 0, 0}, {0, 0, 0, 0}},
+			dir:   DirLeft,
+			want:  [][]uint64{{2, 2, 0, 0}, {0, 0, 0, 0}, {0, 0, 0, 0}, {0, 0, 0, 0}},
+			score: 4,
+		},
+		{
+			name:  "the leading pair combines first",
+			board: [][]uint64{{0, 1, 1, 1}, {0, 0, 0, 0}, {0, 0, 0, 0}, {0, 0, 0, 0}},
+			dir:   DirRight,
+			want:  [][]uint64{{0, 0, 1, 2}, {0, 0, 0, 0}, {0, 0, 0, 0}, {0, 0, 0, 0}},
+			score: 4,
+		},
+		{
+			name:  "gaps close up",
+			board: [][]uint64{{0, 0, 0, 3}, {2, 0, 0, 0}, {0, 0, 0, 0}, {2, 0, 0, 1}},
+			dir:   DirUp,
+			want:  [][]uint64{{3, 0, 0, 3}, {0, 0, 0, 1}, {0, 0, 0, 0}, {0, 0, 0, 0}},
+			score: 8,
+		},
+		{
+			name:  "full lines without pairs stay put",
+			board: [][]uint64{{1, 2, 3, 4}, {2, 3, 4, 5}, {3, 4, 5, 6}, {4, 5, 6, 7}},
+			dir:   DirDown,
+			want:  [][]uint64{{1, 2, 3, 4}, {2, 3, 4, 5}, {3, 4, 5, 6}, {4, 5, 6, 7}},
+		},
+		{
+			name:  "large tiles combine",
+			board: [][]uint64{{14, 14, 0, 0}, {0, 0, 0, 0}, {0, 0, 0, 0}, {0, 0, 0, 0}},
+			dir:   DirLeft,
+			want:  [][]uint64{{15, 0, 0, 0}, {0, 0, 0, 0}, {0, 0, 0, 0}, {0, 0, 0, 0}},
+			score: 1 << 15,
+		},
+		{
+			name:  "rectangles",
+			board: [][]uint64{{1, 0, 1, 2, 2, 3}, {0, 4, 4, 4, 0, 0}},
+			dir:   DirLeft,
+			want:  [][]uint64{{2, 3, 3, 0, 0, 0}, {5, 4, 0, 0, 0, 0}},
+			score: 4 + 8 + 32,
+		},
+	}
+
+	for _, tt := range tests {
+		for name, e := range engines(tt.board) {
+			score, moved := e.move(tt.dir)
+
+			if got := e.board(); !reflect.DeepEqual(got, tt.want) || score != tt.score || moved != !reflect.DeepEqual(tt.board, tt.want) {
+				t.Errorf("%v, %v: got %v scoring %v, want %v scoring %v", tt.name, name, got, score, tt.want, tt.score)
+			}
+		}
+	}
+}
+
+func TestEngineLost(t *testing.T) {
+	tests := []struct {
+		name  string
+		board [][]uint64
+		lost  bool
+	}{
+		{"empty square", [][]uint64{{1, 2, 3, 4}, {2, 3, 4, 5}, {3, 4, 0, 6}, {4, 5, 6, 7}}, false},
+		{"pair in a row", [][]uint64{{1, 2, 3, 4}, {2, 3, 4, 5}, {3, 4, 6, 6}, {4, 5, 7, 8}}, false},
+		{"pair in a column", [][]uint64{{1, 2, 3, 4}, {2, 3, 4, 5}, {3, 4, 5, 6}, {4, 5, 5, 7}}, false},
+		{"stuck", [][]uint64{{1, 2, 3, 4}, {2, 3, 4, 5}, {3, 4, 5, 6}, {4, 5, 6, 7}}, true},
+	}
+
+	for _, tt := range tests {
+		for name, e := range engines(tt.board) {
+			if got := e.lost(); got != tt.lost {
+				t.Errorf("%v, %v: lost() = %v, want %v", tt.name, name, got, tt.lost)
+			}
+		}
+	}
+}
+
+// TestEnginesAgree checks both engines against the reference rules on
+// random boards
+func TestEnginesAgree(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 20000; i++ {
+		height, width := 4, 4
+
+		// every other board is some other size, only the grid handles those
+		if i%2 == 1 {
+			height, width = 1+rnd.Intn(7), 1+rnd.Intn(7)
+		}
+
+		// few distinct values, so plenty of pairs and full boards
+		board := make([][]uint64, height)
+		for row := range board {
+			board[row] = make([]uint64, width)
+			for col := range board[row] {
+				switch {
+				case rnd.Intn(4) == 0:
+					// empty
+
+				case rnd.Intn(16) == 0:
+					// now and then a large tile, so every win limit is checked
+					board[row][col] = uint64(1 + rnd.Intn(bitboardMaxPower))
+
+				default:
+					board[row][col] = uint64(1 + rnd.Intn(4))
+				}
+			}
+		}
+
+		for name, e := range engines(board) {
+			checkEngine(t, name, e, board)
+		}
+	}
+}
+
+// benchmarkMove plays moves in games of the size given, starting a new
+// game whenever one ends
+func benchmarkMove(b *testing.B, width, height, limitPower uint64) {
+	seed := int64(1)
+	g := NewSeededGame(width, height, limitPower, 1, seed)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if g.State() != StatePlaying {
+			b.StopTimer()
+			seed++
+			g = NewSeededGame(width, height, limitPower, 1, seed)
+			b.StartTimer()
+		}
+
+		g.Move(Directions[i%len(Directions)])
+	}
+}
+
+func BenchmarkMove4x4Bitboard(b *testing.B) { benchmarkMove(b, 4, 4, 11) }
+func BenchmarkMove4x4Grid(b *testing.B)     { benchmarkMove(b, 4, 4, 20) }
+func BenchmarkMove8x8(b *testing.B)         { benchmarkMove(b, 8, 8, 20) }
+func BenchmarkMove32x32(b *testing.B)       { benchmarkMove(b, 32, 32, 20) }
+
+// BenchmarkEngines plays the same 4x4 games on each engine directly,
+// doing what a game does each move: move, look for a win or loss and
+// place a tile on an empty square
+func BenchmarkEngines(b *testing.B) {
+	start := [][]uint64{{1, 0, 0, 0}, {0, 0, 1, 0}, {0, 0, 0, 0}, {0, 0, 0, 0}}
+
+	for name, e := range engines(start) {
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+
+			for i := 0; i < b.N; i++ {
+				if _, moved := e.move(Directions[i%len(Directions)]); !moved {
+					continue
+				}
+
+				if e.won(11) || e.lost() {
+					for row := range start {
+						for col, val := range start[row] {
+							e.set(uint64(row), uint64(col), val)
+						}
+					}
+					continue
+				}
+
+				if n := e.empties(); n > 0 {
+					row, col := e.emptyAt(uint64(i) % n)
+					e.set(row, col, 1)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkPlaceNew places tiles on a 32x32 board with only a few empty
+// squares left, taking each one away again afterwards
+func BenchmarkPlaceNew(b *testing.B) {
+	board := make([][]uint64, 32)
+	for row := range board {
+		board[row] = make([]uint64, 32)
+		for col := range board[row] {
+			board[row][col] = uint64((row+col)%10 + 1)
+		}
+	}
+
+	for _, sq := range []Square{{0, 0}, {5, 17}, {13, 2}, {20, 30}, {31, 31}} {
+		board[sq.Row][sq.Col] = 0
+	}
+
+	g, err := NewGameFromBoard(board, 20, 1, 0, 1)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		sp, ok := g.placeNew()
+		if !ok {
+			b.Fatal("board is full")
+		}
+
+		g.setPos(sp.Row, sp.Col, 0)
+	}
+}
+
+// TestMoveDoesNotAllocate guards the moves of both engines against allocating
+func TestMoveDoesNotAllocate(t *testing.T) {
+	for _, size := range []struct{ width, height, limitPower uint64 }{{4, 4, 11}, {4, 4, 20}, {8, 8, 20}, {32, 32, 20}} {
+		g := NewSeededGame(size.width, size.height, size.limitPower, 1, 1)
+		i := 0
+
+		allocs := testing.AllocsPerRun(1000, func() {
+			g.Move(Directions[i%len(Directions)])
+			i++
+		})
+
+		if allocs != 0 {
+			t.Errorf("%vx%v up to 2^%v: %v allocations per move, want 0", size.width, size.height, size.limitPower, allocs)
+		}
+	}
+}
+
+// TestBitboard checks Bitboard against Slide, SpawnOutcomes and the grid
+// engine on random 4x4 boards
+func TestBitboard(t *testing.T) {
+	for _, board := range [][][]uint64{
+		{{1, 2, 3}, {0, 0, 0}, {0, 0, 0}},
+		{{1, 2, 3, 4}, {0, 0, 0, 0}, {0, 0, 0, 0}},
+		{{15, 0, 0, 0}, {0, 0, 0, 0}, {0, 0, 0, 0}, {0, 0, 0, 0}},
+	} {
+		if _, ok := NewBitboard(board); ok {
+			t.Errorf("packed %v", board)
+		}
+	}
+
+	rnd := rand.New(rand.NewSource(2))
+
+	for i := 0; i < 2000; i++ {
+		board := make([][]uint64, bitboardSize)
+		for row := range board {
+			board[row] = make([]uint64, bitboardSize)
+			for col := range board[row] {
+				if rnd.Intn(3) > 0 {
+					board[row][col] = uint64(1 + rnd.Intn(bitboardMaxPower-1))
+				}
+			}
+		}
+
+		b, ok := NewBitboard(board)
+		if !ok {
+			t.Fatalf("couldn't pack %v", board)
+		}
+
+		if !reflect.DeepEqual(b.Board(), board) {
+			t.Fatalf("packed %v as %v", board, b.Board())
+		}
+
+		for _, dir := range Directions {
+			next, score, moved := b.Move(dir)
+			wantBoard, wantScore, wantMoved := Slide(board, dir)
+
+			if !reflect.DeepEqual(next.Board(), wantBoard) || score != wantScore || moved != wantMoved {
+				t.Fatalf("moving %v %v: got %v scoring %v, moved %v, want %v scoring %v, moved %v", board, dir, next.Board(), score, moved, wantBoard, wantScore, wantMoved)
+			}
+		}
+
+		if lost := newGrid(board).lost(); b.Lost() != lost {
+			t.Fatalf("%v: lost %v, want %v", board, b.Lost(), lost)
+		}
+
+		var spawned []SpawnOutcome
+		b.Spawns(func(next Bitboard, prob float64) {
+			spawned = append(spawned, SpawnOutcome{Probability: prob, Spawns: diffSpawns(board, next.Board())})
+		})
+
+		want := SpawnOutcomes(board, 1)
+		if len(want) == 1 && len(want[0].Spawns) == 0 {
+			want = nil
+		}
+
+		if !reflect.DeepEqual(spawned, want) {
+			t.Fatalf("%v: spawned %v, want %v", board, spawned, want)
+		}
+	}
+}
+
+// diffSpawns lists the squares that were empty in before and aren't in after
+func diffSpawns(before, after [][]uint64) []Spawn {
+	var ret []Spawn
+
+	for row := range before {
+		for col, val := range before[row] {
+			if val == 0 && after[row][col] != 0 {
+				ret = append(ret, Spawn{Row: uint64(row), Col: uint64(col), Value: after[row][col]})
+			}
+		}
+	}
+
+	return ret
+}
@@ -3,9 +3,9 @@ package game
 import (
 	crand "crypto/rand"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"io"
-	"math"
 	"math/rand"
 	"strings"
 )
@@ -41,55 +41,110 @@ func (s State) String() string {
 
 // Game represents the game state
 type Game struct {
-	// The board hold the *exponent* of the value, not the value itself.
+	// The engine holds the *exponent* of the value, not the value itself.
 	// 2 is represented as 1, 4 as 2, etc.
-	// This allows much higher target values, up to 2^(2^64)
-	board      [][]uint64
+	eng        engine
 	width      uint64
 	height     uint64
 	limit      uint64
 	adds       uint64
+	seed       int64
+	src        *source
 	rnd        *rand.Rand
 	printWidth int
 	score      uint64
 	moves      uint64
 	state      State
+
+	observers []Observer
+
+	// moves that can be undone and redone, oldest first
+	historyLimit uint64
+	undo         []snapshot
+	redo         []snapshot
+
+	// ids holds the ID of the tile in each square, row major, while
+	// tiles are being tracked, otherwise nil
+	ids    []uint64
+	nextID uint64
+	events []TileEvent
 }
 
-func newRand() *rand.Rand {
+// NewSeed returns a random seed suitable for NewSeededGame
+func NewSeed() int64 {
 	b := make([]byte, 8)
 	crand.Read(b)
-	seed := binary.LittleEndian.Uint64(b)
-	return rand.New(rand.NewSource(int64(seed)))
+	return int64(binary.LittleEndian.Uint64(b))
 }
 
 // NewGame creates a new game board with two randomly placed tiles
 // limitPower is the power of 2 required to win
 func NewGame(width, height, limitPower, adds uint64) *Game {
+	return NewSeededGame(width, height, limitPower, adds, NewSeed())
+}
+
+// NewSeededGame creates a new game like NewGame, but with tiles placed
+// from the given seed. The same seed and the same sequence of moves
+// will always produce the same game.
+func NewSeededGame(width, height, limitPower, adds uint64, seed int64) *Game {
 
 	board := make([][]uint64, height)
 	for i := range board {
 		board[i] = make([]uint64, width)
 	}
 
+	g := newGame(board, limitPower, adds, seed)
+
+	for i := uint64(0); i < adds+1; i++ {
+		g.placeNew()
+	}
+
+	return g
+}
+
+// newGame sets up a game that is still being played on board
+// The board must have at least one row and one column
+func newGame(board [][]uint64, limitPower, adds uint64, seed int64) *Game {
 	longest := fmt.Sprintf("%v", 1<<limitPower)
+	src := newSource(seed)
 
-	g := &Game{
-		board:      board,
-		width:      width,
-		height:     height,
+	return &Game{
+		eng:        newEngine(board, limitPower),
+		width:      uint64(len(board[0])),
+		height:     uint64(len(board)),
 		limit:      limitPower,
 		adds:       adds,
-		rnd:        newRand(),
+		seed:       seed,
+		src:        src,
+		rnd:        rand.New(src),
 		printWidth: len(longest),
 		state:      StatePlaying,
 	}
+}
 
-	for i := uint64(0); i < adds+1; i++ {
-		g.placeNew()
-	}
+// Width reports the number of columns on the board
+func (g *Game) Width() uint64 {
+	return g.width
+}
 
-	return g
+// Height reports the number of rows on the board
+func (g *Game) Height() uint64 {
+	return g.height
+}
+
+// Limit reports the power of 2 required to win
+func (g *Game) Limit() uint64 {
+	return g.limit
+}
+
+// Adds reports the number of new tiles placed after each move
+func (g *Game) Adds() uint64 {
+	return g.adds
+}
+
+// Seed reports the seed the game was created with
+func (g *Game) Seed() int64 {
+	return g.seed
 }
 
 // TotalMoves reports the cumulative number of moves performed in the game
@@ -111,62 +166,81 @@ func (g *Game) State() State {
 // Representation is a 2D array of the power of 2 in the square
 // Array is row major
 func (g *Game) Board() [][]uint64 {
-	ret := make([][]uint64, len(g.board))
-	for i, row := range g.board {
-		ret[i] = make([]uint64, len(row))
-		copy(ret[i], row)
+	return g.eng.board()
+}
+
+// Clone returns an independent copy of the game, including the position
+// of its random number generator
+// Moves made on the clone place the same tiles the original would
+// The clone starts with no history to undo
+func (g *Game) Clone() *Game {
+	src := *g.src
+
+	clone := *g
+	clone.eng = g.eng.clone()
+	clone.src = &src
+	clone.rnd = rand.New(&src)
+	clone.observers = nil
+	clone.historyLimit = 0
+	clone.undo = nil
+	clone.redo = nil
+
+	if g.ids != nil {
+		clone.ids = append([]uint64(nil), g.ids...)
+		clone.events = append([]TileEvent(nil), g.events...)
 	}
 
-	return ret
+	return &clone
 }
 
-func (g *Game) getPos(row, col uint64) uint64 {
-	return g.board[row][col]
+// Reseed restarts the random number generator that places new tiles from seed
+// Seed still reports the seed the game was created with
+// This is meant for simulations, so that clones of the same game can each
+// see different tiles
+func (g *Game) Reseed(seed int64) {
+	g.src.Seed(seed)
+}
+
+// RandState is the state of the generator that places new tiles
+// Games with the same board and RandState place the same tiles after the
+// same moves, however they were created.
+func (g *Game) RandState() uint64 {
+	return g.src.state
+}
+
+// SetRandState puts the generator that places new tiles back in a state
+// from RandState
+func (g *Game) SetRandState(state uint64) {
+	g.src.state = state
 }
 
 func (g *Game) setPos(row, col, newValue uint64) {
-	g.board[row][col] = newValue
+	g.eng.set(row, col, newValue)
 }
 
 // placeNew places a new random tile on the board
 // 90% chance for a 2, 10% chance for a 4
-func (g *Game) placeNew() {
-	if full(g.board) {
-		return
+// Returns the tile placed, or false if the board is full
+func (g *Game) placeNew() (Spawn, bool) {
+	empties := g.eng.empties()
+	if empties == 0 {
+		return Spawn{}, false
 	}
 
 	// first get the random value to place
-	newValue := uint64(2)
+	// the board holds exponents, so these are a 2 and a 4
+	newValue := uint64(spawnTwo)
 
-	if g.rnd.Float64() > 0.9 {
-		newValue = 4
+	if g.rnd.Float64() > 1-probFour {
+		newValue = spawnFour
 	}
 
-	for {
-		// These might overflow, but if it ever does the heat death of the universe will be a concern
-		row := uint64(g.rnd.Int63n(int64(g.height)))
-		col := uint64(g.rnd.Int63n(int64(g.width)))
-
-		// if the board gets full this might take a few tries
-		// if the board is large, this might take a LOT of tries and
-		//   it can be optimized to only pick from a list of open squares
-		if g.getPos(row, col) == 0 {
-			g.setPos(row, col, newValue)
-			break
-		}
-	}
-}
+	// then pick one of the empty squares
+	// This might overflow, but if it ever does the heat death of the universe will be a concern
+	row, col := g.eng.emptyAt(uint64(g.rnd.Int63n(int64(empties))))
+	g.setPos(row, col, newValue)
 
-func full(board [][]uint64) bool {
-	for _, row := range board {
-		for _, val := range row {
-			if val == 0 {
-				return false
-			}
-		}
-	}
-
-	return true
+	return Spawn{Row: row, Col: col, Value: newValue}, true
 }
 
 // Direction is a strongly typed way to represent a move direction
@@ -188,6 +262,17 @@ const (
 	DirRight
 )
 
+// ParseDirection finds the Direction named by s, as returned by Direction.String
+func ParseDirection(s string) (Direction, error) {
+	for _, d := range []Direction{DirUp, DirDown, DirLeft, DirRight} {
+		if strings.EqualFold(s, d.String()) {
+			return d, nil
+		}
+	}
+
+	return dirInvalid, fmt.Errorf("unknown direction %q", s)
+}
+
 func (d Direction) String() string {
 	switch d {
 	case DirUp:
@@ -203,242 +288,195 @@ func (d Direction) String() string {
 	}
 }
 
-// Move makes a move in the direction specified
-// Moves tiles, combines ones that can be combined, and adds a new random tile
-func (g *Game) Move(dir Direction) {
-	// sneaky sneaky
-	if g.state != StatePlaying {
-		return
-	}
+// ErrGameOver is returned when moving in a game that is already won or lost
+var ErrGameOver = errors.New("game: the game is over")
 
-	old := make([][]uint64, g.height)
-	for i := range old {
-		old[i] = make([]uint64, g.width)
-	}
+// ErrInvalidDirection is returned when moving in something that isn't one
+// of the four directions
+var ErrInvalidDirection = errors.New("game: invalid direction")
 
-	for row := uint64(0); row < g.height; row++ {
-		for col := uint64(0); col < g.width; col++ {
-			old[row][col] = g.board[row][col]
-		}
-	}
+// Directions are the four directions a move can be made in
+var Directions = []Direction{DirUp, DirDown, DirLeft, DirRight}
 
-	switch dir {
-	case DirUp:
-		for col := uint64(0); col < g.width; col++ {
-			var cur []uint64
+// Outcome describes what a move did
+type Outcome struct {
+	// Moved is whether anything on the board changed
+	// Moves that change nothing don't place new tiles or count as a move.
+	Moved bool
 
-			for row := uint64(0); row < g.height; row++ {
-				if g.getPos(row, col) != 0 {
-					cur = append(cur, g.getPos(row, col))
-				}
-			}
+	// Score is the points gained
+	Score uint64
 
-			if len(cur) == 0 {
-				continue
-			}
+	// Merges is the number of pairs of tiles combined
+	Merges uint64
 
-			new, score := consolidate(cur, g.height)
-			g.score += score
+	// Spawns are the new tiles placed after the move
+	Spawns []Spawn
 
-			for row := uint64(0); row < g.height; row++ {
-				g.setPos(row, col, new[row])
-			}
-		}
-
-	case DirDown:
-		for col := uint64(0); col < g.width; col++ {
-			var cur []uint64
-
-			for row := g.height - 1; row < math.MaxUint64; row-- {
-				if g.getPos(row, col) != 0 {
-					cur = append(cur, g.getPos(row, col))
-				}
-			}
+	// State is the state of the game after the move
+	State State
+}
 
-			if len(cur) == 0 {
-				continue
-			}
+// Move makes a move in the direction specified
+// Moves tiles, combines ones that can be combined, and adds a new random tile
+// Moves that can't be made are ignored, use Play to find out what happened.
+func (g *Game) Move(dir Direction) {
+	g.move(dir, false)
+}
 
-			new, score := consolidate(cur, g.height)
-			g.score += score
+// Play makes a move in the direction specified, like Move, and describes
+// what it did
+// Returns ErrGameOver if the game is already won or lost, and
+// ErrInvalidDirection for anything but the four directions.
+func (g *Game) Play(dir Direction) (Outcome, error) {
+	return g.move(dir, true)
+}
 
-			for row := uint64(0); row < g.height; row++ {
-				g.setPos(row, col, new[g.height-1-row])
-			}
-		}
+// move makes a move, only counting the merges and collecting the new tiles
+// for the outcome when full is set, to keep Move cheap
+func (g *Game) move(dir Direction, full bool) (Outcome, error) {
+	if g.ids != nil {
+		g.events = nil
+	}
 
-	case DirLeft:
-		for row := uint64(0); row < g.height; row++ {
-			var cur []uint64
+	// sneaky sneaky
+	if g.state != StatePlaying {
+		return Outcome{State: g.state}, ErrGameOver
+	}
 
-			for col := uint64(0); col < g.width; col++ {
-				if g.getPos(row, col) != 0 {
-					cur = append(cur, g.getPos(row, col))
-				}
-			}
+	if dir < DirUp || dir > DirRight {
+		return Outcome{State: g.state}, ErrInvalidDirection
+	}
 
-			if len(cur) == 0 {
-				continue
-			}
+	var before snapshot
+	if g.historyLimit > 0 {
+		before = g.snapshot()
+	}
 
-			new, score := consolidate(cur, g.width)
-			g.score += score
+	var events []TileEvent
+	if g.ids != nil {
+		events = g.trackMove(dir)
+	}
 
-			for col := uint64(0); col < g.width; col++ {
-				g.setPos(row, col, new[col])
-			}
-		}
+	var merges []Spawn
+	if len(g.observers) > 0 {
+		merges = g.findMerges(dir)
+	}
 
-	case DirRight:
-		for row := uint64(0); row < g.height; row++ {
-			var cur []uint64
+	oldState := g.state
 
-			for col := g.width - 1; col < math.MaxUint64; col-- {
-				if g.getPos(row, col) != 0 {
-					cur = append(cur, g.getPos(row, col))
-				}
-			}
+	var emptyBefore uint64
+	if full {
+		emptyBefore = g.eng.empties()
+	}
 
-			if len(cur) == 0 {
-				continue
-			}
+	score, moved := g.eng.move(dir)
+	g.score += score
 
-			new, score := consolidate(cur, g.width)
-			g.score += score
+	out := Outcome{Moved: moved, Score: score}
 
-			for col := uint64(0); col < g.width; col++ {
-				g.setPos(row, col, new[g.width-1-col])
-			}
-		}
+	// every merge leaves one more empty square
+	if full {
+		out.Merges = g.eng.empties() - emptyBefore
 	}
 
-	if !same(old, g.board) {
+	if moved {
 		g.moves++
+
+		if g.historyLimit > 0 {
+			g.pushUndo(before)
+			g.redo = nil
+		}
 	}
 
 	g.setWonOrLost()
 
 	// if old and new boards are the same, don't place new
-	if g.state == StatePlaying && !same(old, g.board) {
+	if g.state == StatePlaying && moved {
 		for i := uint64(0); i < g.adds; i++ {
-			g.placeNew()
-		}
-	}
-}
+			spawn, ok := g.placeNew()
+
+			if !ok {
+				continue
+			}
+
+			// only keep track when someone is listening
+			if full || len(g.observers) > 0 {
+				out.Spawns = append(out.Spawns, spawn)
+			}
 
-func same(old, new [][]uint64) bool {
-	for row := 0; row < len(old); row++ {
-		for col := 0; col < len(old[0]); col++ {
-			if old[row][col] != new[row][col] {
-				return false
+			if g.ids != nil {
+				events = append(events, g.trackSpawn(spawn))
 			}
 		}
+
+		// the new tiles might have filled the last gap
+		g.setWonOrLost()
 	}
 
-	return true
-}
+	out.State = g.state
 
-func (g *Game) setWonOrLost() {
-	if isWin(g.board, g.limit) {
-		g.state = StateWon
-	} else if isLose(g.board) {
-		g.state = StateLost
+	if g.ids != nil && moved {
+		g.events = events
 	}
-}
 
-func isWin(board [][]uint64, winValue uint64) bool {
-	for _, row := range board {
-		for _, val := range row {
-			if val == winValue {
-				return true
-			}
-		}
+	if moved {
+		g.notify(dir, score, merges, out.Spawns, oldState)
 	}
 
-	return false
+	return out, nil
 }
 
-func isLose(board [][]uint64) bool {
-	height := len(board)
-	width := len(board[0])
-
-	for row := 0; row < height; row++ {
-		for col := 0; col < width; col++ {
-			// blank space means there's still a move
-			if board[row][col] == 0 {
-				return false
-			}
+// notify tells every observer about a move that changed the board
+func (g *Game) notify(dir Direction, score uint64, merges, spawns []Spawn, oldState State) {
+	for _, o := range g.observers {
+		o.Moved(dir, score)
 
-			// up
-			if row > 1 &&
-				board[row][col] == board[row-1][col] {
-				return false
-			}
+		for _, m := range merges {
+			o.Merged(Square{m.Row, m.Col}, m.Value)
+		}
 
-			// down
-			if row < height-1 &&
-				board[row][col] == board[row+1][col] {
-				return false
-			}
+		if score > 0 {
+			o.ScoreChanged(g.score-score, g.score)
+		}
 
-			// left
-			if col > 1 &&
-				board[row][col] == board[row][col-1] {
-				return false
-			}
+		for _, sp := range spawns {
+			o.Spawned(sp)
+		}
 
-			// right
-			if col < width-1 &&
-				board[row][col] == board[row][col+1] {
-				return false
-			}
+		if g.state != oldState {
+			o.StateChanged(oldState, g.state)
 		}
 	}
-
-	return true
 }
 
-// Consolidates pairs of numbers and returns a new, padded slice and the added score
-// assumes the slice is more than 0 length
-func consolidate(cur []uint64, padTo uint64) ([]uint64, uint64) {
-	if len(cur) == 0 {
-		panic("SHORT SLICE")
-	}
-
-	new := make([]uint64, 0, padTo)
-	var score uint64
-
-	if len(cur) == 1 {
-		new = cur
-
-	} else {
-		// combine numbers that are the same by twos
-		i := 0
-		for {
-			if i > len(cur)-1 {
-				break
-			}
+// Simulate reports what moving in the direction specified would do
+// without changing the game or placing any new tiles
+// Returns the resulting board, the score gained and whether anything moved
+func (g *Game) Simulate(dir Direction) ([][]uint64, uint64, bool) {
+	e := g.eng.clone()
+	score, moved := e.move(dir)
 
-			if i == len(cur)-1 || cur[i] != cur[i+1] {
-				new = append(new, cur[i])
-
-			} else {
-				new = append(new, cur[i]+1)
+	return e.board(), score, moved
+}
 
-				score += uint64(1) << (cur[i] + 1)
+// Slide moves and combines the tiles of board in the direction specified
+// The board is not modified and no new tiles are placed
+// Returns the resulting board, the score gained and whether anything moved
+func Slide(board [][]uint64, dir Direction) ([][]uint64, uint64, bool) {
+	// leave room for tiles to combine without outgrowing the engine
+	e := newEngine(board, bitboardMaxPower-1)
+	score, moved := e.move(dir)
 
-				i++
-			}
-
-			i++
-		}
-	}
+	return e.board(), score, moved
+}
 
-	for uint64(len(new)) < padTo {
-		new = append(new, 0)
+func (g *Game) setWonOrLost() {
+	if g.eng.won(g.limit) {
+		g.state = StateWon
+	} else if g.eng.lost() {
+		g.state = StateLost
 	}
-
-	return new, score
 }
 
 func (g *Game) String() string {
@@ -464,7 +502,7 @@ func (g *Game) String() string {
 
 	bdr.WriteRune('\n')
 
-	for ridx, row := range g.board {
+	for ridx, row := range g.Board() {
 		// Write out the values in the row
 		bdr.WriteRune('║')
 
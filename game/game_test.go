@@ -0,0 +1,180 @@
+package game
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPlay(t *testing.T) {
+	tests := []struct {
+		name   string
+		pos    string
+		dir    Direction
+		err    error
+		moved  bool
+		score  uint64
+		merges uint64
+		state  State
+	}{
+		{
+			name:   "merges",
+			pos:    "1122/0000/0000/3330",
+			dir:    DirLeft,
+			moved:  true,
+			score:  4 + 8 + 16,
+			merges: 3,
+			state:  StatePlaying,
+		},
+		{
+			name:  "slide without merging",
+			pos:   "0001/0000/0000/0200",
+			dir:   DirLeft,
+			moved: true,
+			state: StatePlaying,
+		},
+		{
+			name:  "nothing moves",
+			pos:   "1200/0000/0000/0000",
+			dir:   DirLeft,
+			state: StatePlaying,
+		},
+		{
+			name:   "winning",
+			pos:    "aa00/0000/0000/0000",
+			dir:    DirRight,
+			moved:  true,
+			score:  1 << 11,
+			merges: 1,
+			state:  StateWon,
+		},
+		{
+			name:  "invalid direction",
+			pos:   "1100/0000/0000/0000",
+			dir:   Direction(9),
+			err:   ErrInvalidDirection,
+			state: StatePlaying,
+		},
+		{
+			name:  "already lost",
+			pos:   "1234/2345/3456/4567",
+			dir:   DirLeft,
+			err:   ErrGameOver,
+			state: StateLost,
+		},
+		{
+			name:  "already won",
+			pos:   "b100/0000/0000/0000",
+			dir:   DirRight,
+			err:   ErrGameOver,
+			state: StateWon,
+		},
+	}
+
+	for _, tt := range tests {
+		g, err := ParsePosition(tt.pos, 1)
+		if err != nil {
+			t.Fatalf("%v: %v", tt.name, err)
+		}
+
+		out, err := g.Play(tt.dir)
+		if err != tt.err {
+			t.Errorf("%v: got error %v, want %v", tt.name, err, tt.err)
+		}
+
+		if out.Moved != tt.moved || out.Score != tt.score || out.Merges != tt.merges || out.State != tt.state {
+			t.Errorf("%v: got moved %v, score %v, merges %v, state %v, want %v, %v, %v, %v",
+				tt.name, out.Moved, out.Score, out.Merges, out.State, tt.moved, tt.score, tt.merges, tt.state)
+		}
+
+		// only moves that change the board place tiles, and not once the game is won
+		wantSpawns := 0
+		if tt.moved && tt.state == StatePlaying {
+			wantSpawns = 1
+		}
+
+		if len(out.Spawns) != wantSpawns {
+			t.Errorf("%v: got %v new tiles, want %v", tt.name, len(out.Spawns), wantSpawns)
+		}
+
+		if g.Score() != tt.score || g.State() != tt.state {
+			t.Errorf("%v: game scored %v in state %v, want %v in %v", tt.name, g.Score(), g.State(), tt.score, tt.state)
+		}
+	}
+}
+
+// TestPlayMatchesMove checks Play makes exactly the moves Move does
+func TestPlayMatchesMove(t *testing.T) {
+	moved := NewSeededGame(4, 4, 11, 1, 3)
+	played := NewSeededGame(4, 4, 11, 1, 3)
+
+	for i := 0; moved.State() == StatePlaying; i++ {
+		dir := Directions[(i*5/3)%len(Directions)]
+		moved.Move(dir)
+
+		if _, err := played.Play(dir); err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(played.Board(), moved.Board()) || played.Score() != moved.Score() || played.State() != moved.State() {
+			t.Fatalf("after %v moves Play gave\n%v\nwant\n%v", i+1, played, moved)
+		}
+	}
+}
+
+// TestCloneIndependent checks moving a clone leaves the original alone
+func TestCloneIndependent(t *testing.T) {
+	g := NewSeededGame(4, 4, 11, 1, 5)
+	g.TrackTiles(true)
+	g.Move(DirLeft)
+	g.Move(DirDown)
+
+	board, ids, score := g.Board(), g.TileIDs(), g.Score()
+	events := append([]TileEvent(nil), g.TileEvents()...)
+
+	clone := g.Clone()
+	if !reflect.DeepEqual(clone.TileEvents(), events) {
+		t.Fatalf("clone has events %v, want %v", clone.TileEvents(), events)
+	}
+
+	for i := 0; i < 20 && clone.State() == StatePlaying; i++ {
+		clone.Move(Directions[i%len(Directions)])
+	}
+
+	if !reflect.DeepEqual(g.Board(), board) || !reflect.DeepEqual(g.TileIDs(), ids) || g.Score() != score {
+		t.Fatalf("moving the clone changed the original to\n%v", g)
+	}
+
+	if !reflect.DeepEqual(g.TileEvents(), events) {
+		t.Fatalf("moving the clone changed the original's events to %v, want %v", g.TileEvents(), events)
+	}
+
+	// and the original places the same tiles the clone did
+	replayed := g.Clone()
+	for i := 0; i < 20 && replayed.State() == StatePlaying; i++ {
+		replayed.Move(Directions[i%len(Directions)])
+	}
+
+	if !reflect.DeepEqual(replayed.Board(), clone.Board()) {
+		t.Fatalf("clones of the same game ended at\n%v\nand\n%v", replayed, clone)
+	}
+}
+
+// TestLostAfterSpawn checks a move that leaves one gap is lost as soon as
+// the new tile fills it, rather than on the next move
+func TestLostAfterSpawn(t *testing.T) {
+	for seed := int64(0); seed < 10; seed++ {
+		g, err := ParsePosition("3456/4567/5678/0678", seed)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		out, err := g.Play(DirLeft)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(out.Spawns) != 1 || out.State != StateLost || g.State() != StateLost {
+			t.Fatalf("seed %v: placed %v and ended %v, want one tile placed and lost", seed, out.Spawns, out.State)
+		}
+	}
+}
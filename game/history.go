@@ -0,0 +1,123 @@
+package game
+
+// snapshot is everything Undo and Redo need to put a game back how it was
+type snapshot struct {
+	eng   engine
+	score uint64
+	moves uint64
+	state State
+
+	// rng is the state of the random number generator, so that moving the
+	// same way again places the same tiles
+	rng uint64
+
+	// ids are the tile IDs, if tiles are being tracked
+	ids []uint64
+}
+
+func (g *Game) snapshot() snapshot {
+	return snapshot{
+		eng:   g.eng.clone(),
+		score: g.score,
+		moves: g.moves,
+		state: g.state,
+		rng:   g.src.state,
+		ids:   append([]uint64(nil), g.ids...),
+	}
+}
+
+func (g *Game) restore(s snapshot) {
+	g.eng = s.eng.clone()
+	g.score = s.score
+	g.moves = s.moves
+	g.state = s.state
+	g.src.state = s.rng
+
+	// tracking might have been turned on or off since
+	if g.ids != nil && s.ids != nil {
+		copy(g.ids, s.ids)
+	} else if g.ids != nil {
+		g.ids = nil
+		g.TrackTiles(true)
+	}
+
+	g.events = nil
+}
+
+// SetHistory keeps up to limit moves that can be undone
+// History is off by default, and setting 0 turns it off and forgets
+// everything kept so far.
+func (g *Game) SetHistory(limit uint64) {
+	g.historyLimit = limit
+
+	if limit == 0 {
+		g.undo = nil
+		g.redo = nil
+		return
+	}
+
+	if uint64(len(g.undo)) > limit {
+		g.undo = g.undo[uint64(len(g.undo))-limit:]
+	}
+}
+
+// CanUndo reports whether there is a move to undo
+func (g *Game) CanUndo() bool {
+	return len(g.undo) > 0
+}
+
+// CanRedo reports whether there is an undone move to redo
+func (g *Game) CanRedo() bool {
+	return len(g.redo) > 0
+}
+
+// Undo takes back the last move, including the tiles placed after it
+// Returns false if there is nothing to undo
+func (g *Game) Undo() bool {
+	if len(g.undo) == 0 {
+		return false
+	}
+
+	g.redo = append(g.redo, g.snapshot())
+
+	last := g.undo[len(g.undo)-1]
+	g.undo = g.undo[:len(g.undo)-1]
+	g.restore(last)
+
+	for _, o := range g.observers {
+		o.Undone()
+	}
+
+	return true
+}
+
+// Redo plays the last undone move again, placing the same tiles
+// Returns false if there is nothing to redo
+// Making any other move forgets everything that could be redone.
+func (g *Game) Redo() bool {
+	if len(g.redo) == 0 {
+		return false
+	}
+
+	g.pushUndo(g.snapshot())
+
+	last := g.redo[len(g.redo)-1]
+	g.redo = g.redo[:len(g.redo)-1]
+	g.restore(last)
+
+	for _, o := range g.observers {
+		o.Redone()
+	}
+
+	return true
+}
+
+// pushUndo keeps s as the latest move to undo, forgetting the oldest if needed
+func (g *Game) pushUndo(s snapshot) {
+	if uint64(len(g.undo)) >= g.historyLimit {
+		copy(g.undo, g.undo[1:])
+		g.undo = g.undo[:len(g.undo)-1]
+	}
+
+	g.undo = append(g.undo, s)
+}
@@ -0,0 +1,93 @@
+package game
+
+// Observer is told about everything that happens during moves
+// Embed NopObserver to only handle some of them.
+// Calls happen in this order for each move: Moved, Merged for each merge,
+// ScoreChanged, Spawned for each new tile, then StateChanged.
+// Undo, Redo and loading a saved game are reported after they happen.
+type Observer interface {
+	// Moved is called for every move that changes the board, with the
+	// score it gained
+	Moved(dir Direction, score uint64)
+
+	// Merged is called for every pair of tiles combined, with the square of
+	// the new tile and its power of 2
+	Merged(at Square, value uint64)
+
+	// ScoreChanged is called when a move adds to the score
+	ScoreChanged(old, new uint64)
+
+	// Spawned is called for every new tile placed after a move
+	Spawned(Spawn)
+
+	// StateChanged is called when the game is won or lost
+	StateChanged(old, new State)
+
+	// Undone is called when Undo takes back a move
+	Undone()
+
+	// Redone is called when Redo plays an undone move again
+	Redone()
+
+	// Loaded is called when a saved game replaces the game
+	Loaded()
+}
+
+// NopObserver does nothing when told about a game
+// Embed it in observers that only care about some calls.
+type NopObserver struct{}
+
+// Moved does nothing
+func (NopObserver) Moved(Direction, uint64) {}
+
+// Merged does nothing
+func (NopObserver) Merged(Square, uint64) {}
+
+// ScoreChanged does nothing
+func (NopObserver) ScoreChanged(uint64, uint64) {}
+
+// Spawned does nothing
+func (NopObserver) Spawned(Spawn) {}
+
+// StateChanged does nothing
+func (NopObserver) StateChanged(State, State) {}
+
+// Undone does nothing
+func (NopObserver) Undone() {}
+
+// Redone does nothing
+func (NopObserver) Redone() {}
+
+// Loaded does nothing
+func (NopObserver) Loaded() {}
+
+// AddObserver starts telling o about every move
+// Clones don't keep their observers.
+func (g *Game) AddObserver(o Observer) {
+	g.observers = append(g.observers, o)
+}
+
+// RemoveObserver stops telling o about moves
+// Observers are compared with ==, so pointers work best.
+func (g *Game) RemoveObserver(o Observer) {
+	for i, obs := range g.observers {
+		if obs == o {
+			g.observers = append(g.observers[:i:i], g.observers[i+1:]...)
+			return
+		}
+	}
+}
+
+// findMerges lists where tiles will combine when moving in dir
+// Must be called before the engine makes the move.
+func (g *Game) findMerges(dir Direction) []Spawn {
+	var merges []Spawn
+
+	for _, tm := range planMove(g.eng, dir, g.width, g.height) {
+		if tm.merged {
+			merges = append(merges, Spawn{Row: tm.to.Row, Col: tm.to.Col, Value: tm.value + 1})
+		}
+	}
+
+	return merges
+}
@@ -0,0 +1,124 @@
+package game
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// callLog writes down every call an observer gets
+type callLog struct {
+	calls []string
+}
+
+func (l *callLog) add(format string, args ...interface{}) {
+	l.calls = append(l.calls, fmt.Sprintf(format, args...))
+}
+
+func (l *callLog) Moved(dir Direction, score uint64) { l.add("Moved %v %v", dir, score) }
+func (l *callLog) Merged(at Square, value uint64)    { l.add("Merged %v,%v %v", at.Row, at.Col, value) }
+func (l *callLog) ScoreChanged(old, new uint64)      { l.add("ScoreChanged %v %v", old, new) }
+func (l *callLog) Spawned(sp Spawn)                  { l.add("Spawned %v,%v %v", sp.Row, sp.Col, sp.Value) }
+func (l *callLog) StateChanged(old, new State)       { l.add("StateChanged %v %v", old, new) }
+func (l *callLog) Undone()                           { l.add("Undone") }
+func (l *callLog) Redone()                           { l.add("Redone") }
+func (l *callLog) Loaded()                           { l.add("Loaded") }
+
+// take returns the calls so far and forgets them
+func (l *callLog) take() []string {
+	calls := l.calls
+	l.calls = nil
+	return calls
+}
+
+func TestObserverMoves(t *testing.T) {
+	g, err := ParsePosition("1122/0000/a000/0000 10", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l := &callLog{}
+	g.AddObserver(l)
+
+	out, err := g.Play(DirLeft)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sp := out.Spawns[0]
+	want := []string{
+		"Moved Left 12",
+		"Merged 0,0 2",
+		"Merged 0,1 3",
+		"ScoreChanged 10 22",
+		fmt.Sprintf("Spawned %v,%v %v", sp.Row, sp.Col, sp.Value),
+	}
+
+	if got := l.take(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+
+	// nothing moves left again, so nothing is reported
+	g.Play(DirLeft)
+	if got := l.take(); got != nil {
+		t.Fatalf("got %q for a move that changed nothing", got)
+	}
+
+	// winning places nothing after the move
+	g, err = ParsePosition("0000/0000/a000/a000", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g.AddObserver(l)
+	g.Play(DirUp)
+
+	want = []string{"Moved Up 2048", "Merged 0,0 11", "ScoreChanged 0 2048", "StateChanged Playing Won"}
+	if got := l.take(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestObserverHistory(t *testing.T) {
+	g := NewSeededGame(4, 4, 11, 1, 6)
+	g.SetHistory(5)
+
+	saved, err := g.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l := &callLog{}
+	g.AddObserver(l)
+
+	g.Move(DirLeft)
+	l.take()
+
+	g.Undo()
+	g.Redo()
+	if err := g.UnmarshalBinary(saved); err != nil {
+		t.Fatal(err)
+	}
+
+	// nothing to undo or redo isn't reported
+	g.Undo()
+	g.Redo()
+
+	if got, want := l.take(), []string{"Undone", "Redone", "Loaded"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+
+	// observers carry on after loading, until removed
+	g.Move(DirLeft)
+	g.Move(DirUp)
+	if got := l.take(); len(got) == 0 {
+		t.Fatal("nothing reported after loading")
+	}
+
+	g.RemoveObserver(l)
+	g.Move(DirRight)
+	g.Move(DirDown)
+	if got := l.take(); got != nil {
+		t.Fatalf("got %q after removing the observer", got)
+	}
+}
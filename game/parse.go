@@ -0,0 +1,170 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+)
+
+// ErrBadBoard is returned for boards that are empty or not rectangular
+var ErrBadBoard = errors.New("game: board must have at least one square and rows of the same length")
+
+// NewGameFromBoard creates a game at the position on board, which holds the
+// power of 2 in each square like Game.Board
+// The game starts with the given score and no moves made. New tiles are
+// placed from seed. It might already be won or lost.
+func NewGameFromBoard(board [][]uint64, limitPower, adds, score uint64, seed int64) (*Game, error) {
+	if err := checkBoard(board); err != nil {
+		return nil, err
+	}
+
+	g := newGame(board, limitPower, adds, seed)
+	g.score = score
+	g.setWonOrLost()
+
+	return g, nil
+}
+
+// Parse reads a board with ParseBoard and creates a game at that position
+// with NewGameFromBoard
+func Parse(text string, limitPower, adds, score uint64, seed int64) (*Game, error) {
+	board, err := ParseBoard(text)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewGameFromBoard(board, limitPower, adds, score, seed)
+}
+
+// ParseBoard reads a board written out by Game.String, or a simpler grid
+// with a line per row and tile values separated by commas or spaces
+// In the simple grid, empty squares are 0 or a dot.
+// Like Game.Board, the result holds the power of 2 in each square.
+func ParseBoard(text string) ([][]uint64, error) {
+	var board [][]uint64
+	var err error
+
+	if strings.ContainsRune(text, '║') {
+		board, err = parseBoxes(text)
+	} else {
+		board, err = parseGrid(text)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkBoard(board); err != nil {
+		return nil, err
+	}
+
+	return board, nil
+}
+
+// parseBoxes reads the box drawing format from Game.String
+func parseBoxes(text string) ([][]uint64, error) {
+	var board [][]uint64
+
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+
+		// only the rows with values matter, the rest are borders
+		if !strings.HasPrefix(line, "║") {
+			continue
+		}
+
+		line = strings.Trim(line, "║")
+		line = strings.ReplaceAll(line, "║", "│")
+
+		var row []uint64
+		for _, cell := range strings.Split(line, "│") {
+			val, err := parseTile(strings.TrimSpace(cell))
+			if err != nil {
+				return nil, err
+			}
+
+			row = append(row, val)
+		}
+
+		board = append(board, row)
+	}
+
+	return board, nil
+}
+
+// parseGrid reads rows of values separated by commas or spaces
+func parseGrid(text string) ([][]uint64, error) {
+	var board [][]uint64
+
+	for _, line := range strings.Split(text, "\n") {
+		fields := strings.FieldsFunc(line, func(r rune) bool {
+			return r == ',' || r == ' ' || r == '\t' || r == '\r'
+		})
+
+		if len(fields) == 0 {
+			continue
+		}
+
+		var row []uint64
+		for _, field := range fields {
+			if field == "." {
+				field = ""
+			}
+
+			val, err := parseTile(field)
+			if err != nil {
+				return nil, err
+			}
+
+			row = append(row, val)
+		}
+
+		board = append(board, row)
+	}
+
+	return board, nil
+}
+
+// parseTile turns the value shown on a tile into its power of 2
+// Blank and 0 are empty squares.
+func parseTile(s string) (uint64, error) {
+	if s == "" {
+		return 0, nil
+	}
+
+	val, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("game: bad tile %q", s)
+	}
+
+	return valueToPower(val)
+}
+
+// valueToPower turns a tile value into its power of 2, 0 stays 0
+func valueToPower(val uint64) (uint64, error) {
+	if val == 0 {
+		return 0, nil
+	}
+
+	if val == 1 || val&(val-1) != 0 {
+		return 0, fmt.Errorf("game: tile %v is not a power of 2", val)
+	}
+
+	return uint64(bits.TrailingZeros64(val)), nil
+}
+
+func checkBoard(board [][]uint64) error {
+	if len(board) == 0 || len(board[0]) == 0 {
+		return ErrBadBoard
+	}
+
+	for _, row := range board {
+		if len(row) != len(board[0]) {
+			return ErrBadBoard
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,77 @@
+package game
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseString(t *testing.T) {
+	boards := [][][]uint64{
+		{{1, 0, 0, 0}, {0, 2, 0, 0}, {0, 0, 3, 0}, {0, 0, 0, 11}},
+		{{0, 0, 0}, {0, 0, 0}},
+		{{1, 2, 3, 4, 5}, {6, 7, 8, 9, 10}, {11, 12, 13, 14, 15}},
+		{{17}},
+		{{0, 20}, {30, 1}},
+	}
+
+	for _, board := range boards {
+		g, err := NewGameFromBoard(board, 11, 1, 0, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		parsed, err := Parse(g.String(), 11, 1, 0, 1)
+		if err != nil {
+			t.Fatalf("parsing\n%v: %v", g, err)
+		}
+
+		if got := parsed.Board(); !reflect.DeepEqual(got, board) {
+			t.Errorf("parsing\n%v gave %v, want %v", g, got, board)
+		}
+	}
+}
+
+func TestParseBoard(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want [][]uint64
+	}{
+		{"spaces", "2 0 0 4\n0 8 0 0", [][]uint64{{1, 0, 0, 2}, {0, 3, 0, 0}}},
+		{"commas", "2,4\n.,2048", [][]uint64{{1, 2}, {0, 11}}},
+		{"blank lines and padding", "\n  2  4 \r\n\n 8 16\n", [][]uint64{{1, 2}, {3, 4}}},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseBoard(tt.text)
+		if err != nil {
+			t.Errorf("%v: %v", tt.name, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%v: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseBoardErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+	}{
+		{"empty", ""},
+		{"ragged rows", "2 4\n8"},
+		{"ragged boxes", "║ 2│ 4║\n║ 8║"},
+		{"not a power of 2", "2 3"},
+		{"1 is not a tile", "1 2"},
+		{"not a number", "2 x"},
+		{"negative", "2 -4"},
+	}
+
+	for _, tt := range tests {
+		if board, err := ParseBoard(tt.text); err == nil {
+			t.Errorf("%v: got %v, want an error", tt.name, board)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package game
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Defaults for the optional fields of a position
+const (
+	DefaultLimit = 11
+	DefaultAdds  = 1
+)
+
+// positionDigits are the characters for powers of 2 from 0 to 35 in a position
+// Larger powers are written in decimal in brackets, like (40)
+const positionDigits = "0123456789abcdefghijklmnopqrstuvwxyz"
+
+// Position describes the game in one line, in the form written by FormatPosition
+func (g *Game) Position() string {
+	return FormatPosition(g.Board(), g.score, g.limit, g.adds)
+}
+
+// FormatPosition describes a position in one line, like
+//
+//	1200/0000/0010/000b 1234 11 1
+//
+// The first field is the board a row at a time, separated by slashes.
+// Each square is the power of 2 of its tile as a base 36 digit, 0 is empty.
+// Powers of 36 or more are written in decimal in brackets.
+// After that come the score, the power of 2 needed to win and the number
+// of tiles added after each move.
+func FormatPosition(board [][]uint64, score, limitPower, adds uint64) string {
+	bdr := &strings.Builder{}
+
+	for i, row := range board {
+		if i > 0 {
+			bdr.WriteRune('/')
+		}
+
+		for _, val := range row {
+			if val < uint64(len(positionDigits)) {
+				bdr.WriteByte(positionDigits[val])
+			} else {
+				fmt.Fprintf(bdr, "(%v)", val)
+			}
+		}
+	}
+
+	fmt.Fprintf(bdr, " %v %v %v", score, limitPower, adds)
+
+	return bdr.String()
+}
+
+// ParsePosition creates a game at a position written by FormatPosition
+// The score, limit and adds can be left off the end, and default to 0,
+// DefaultLimit and DefaultAdds. New tiles are placed from seed.
+func ParsePosition(s string, seed int64) (*Game, error) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 || len(fields) > 4 {
+		return nil, fmt.Errorf("game: position %q should have 1 to 4 fields", s)
+	}
+
+	board, err := parsePositionBoard(fields[0])
+	if err != nil {
+		return nil, err
+	}
+
+	nums := []uint64{0, DefaultLimit, DefaultAdds}
+	for i, field := range fields[1:] {
+		nums[i], err = strconv.ParseUint(field, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("game: bad number %q in position", field)
+		}
+	}
+
+	return NewGameFromBoard(board, nums[1], nums[2], nums[0], seed)
+}
+
+func parsePositionBoard(s string) ([][]uint64, error) {
+	var board [][]uint64
+
+	for _, line := range strings.Split(s, "/") {
+		var row []uint64
+
+		for i := 0; i < len(line); i++ {
+			if line[i] == '(' {
+				end := strings.IndexByte(line[i:], ')')
+				if end < 0 {
+					return nil, fmt.Errorf("game: unclosed bracket in position row %q", line)
+				}
+
+				val, err := strconv.ParseUint(line[i+1:i+end], 10, 64)
+				if err != nil {
+					return nil, fmt.Errorf("game: bad square in position row %q", line)
+				}
+
+				row = append(row, val)
+				i += end
+				continue
+			}
+
+			val := strings.IndexByte(positionDigits, lower(line[i]))
+			if val < 0 {
+				return nil, fmt.Errorf("game: bad square %q in position row %q", line[i], line)
+			}
+
+			row = append(row, uint64(val))
+		}
+
+		board = append(board, row)
+	}
+
+	if err := checkBoard(board); err != nil {
+		return nil, err
+	}
+
+	return board, nil
+}
+
+func lower(c byte) byte {
+	if c >= 'A' && c <= 'Z' {
+		return c - 'A' + 'a'
+	}
+
+	return c
+}
@@ -0,0 +1,84 @@
+package game
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePosition(t *testing.T) {
+	tests := []struct {
+		pos   string
+		board [][]uint64
+		score uint64
+		limit uint64
+		adds  uint64
+	}{
+		{"1200/0000/0010/000b 1234 11 1", [][]uint64{{1, 2, 0, 0}, {0, 0, 0, 0}, {0, 0, 1, 0}, {0, 0, 0, 11}}, 1234, 11, 1},
+		{"12/34", [][]uint64{{1, 2}, {3, 4}}, 0, DefaultLimit, DefaultAdds},
+		{"10/01 8", [][]uint64{{1, 0}, {0, 1}}, 8, DefaultLimit, DefaultAdds},
+		{"aZ0/000 0 40 2", [][]uint64{{10, 35, 0}, {0, 0, 0}}, 0, 40, 2},
+		{"(36)(40)1 0 50", [][]uint64{{36, 40, 1}}, 0, 50, DefaultAdds},
+	}
+
+	for _, tt := range tests {
+		g, err := ParsePosition(tt.pos, 1)
+		if err != nil {
+			t.Errorf("%q: %v", tt.pos, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(g.Board(), tt.board) || g.Score() != tt.score || g.Limit() != tt.limit || g.Adds() != tt.adds {
+			t.Errorf("%q: got %v scoring %v up to 2^%v adding %v, want %v scoring %v up to 2^%v adding %v",
+				tt.pos, g.Board(), g.Score(), g.Limit(), g.Adds(), tt.board, tt.score, tt.limit, tt.adds)
+		}
+
+		// reading back what it writes gives the same game
+		again, err := ParsePosition(g.Position(), 1)
+		if err != nil {
+			t.Errorf("%q: reading back %q: %v", tt.pos, g.Position(), err)
+			continue
+		}
+
+		if again.Position() != g.Position() || !reflect.DeepEqual(again.Board(), g.Board()) {
+			t.Errorf("%q: read back as %q, want %q", tt.pos, again.Position(), g.Position())
+		}
+	}
+}
+
+// TestPositionRoundTrip writes out games part way through and reads them back
+func TestPositionRoundTrip(t *testing.T) {
+	for _, size := range []struct{ width, height uint64 }{{4, 4}, {3, 5}, {6, 2}} {
+		g := NewSeededGame(size.width, size.height, 11, 1, 2)
+		for i := 0; i < 30; i++ {
+			g.Move(Directions[i%len(Directions)])
+		}
+
+		parsed, err := ParsePosition(g.Position(), 1)
+		if err != nil {
+			t.Fatalf("%q: %v", g.Position(), err)
+		}
+
+		if !reflect.DeepEqual(parsed.Board(), g.Board()) || parsed.Score() != g.Score() || parsed.State() != g.State() {
+			t.Errorf("%q: read back as %q", g.Position(), parsed.Position())
+		}
+	}
+}
+
+func TestParsePositionErrors(t *testing.T) {
+	for _, pos := range []string{
+		"",
+		"12/3",
+		"12/34 0 11 1 5",
+		"12/34 x",
+		"12/34 -1",
+		"1!/34",
+		"(12/34",
+		"(x)1/34",
+		"/",
+		"12//34",
+	} {
+		if g, err := ParsePosition(pos, 1); err == nil {
+			t.Errorf("%q: got %v, want an error", pos, g.Board())
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package game
+
+// source is a splitmix64 generator used as the rand.Source for games.
+// Its whole state is a single uint64, so it is cheap to copy when a
+// game is cloned.
+type source struct {
+	state uint64
+}
+
+func newSource(seed int64) *source {
+	return &source{state: uint64(seed)}
+}
+
+// Seed resets the generator to the start of the sequence for seed
+func (s *source) Seed(seed int64) {
+	s.state = uint64(seed)
+}
+
+// Uint64 returns the next pseudo-random 64 bit value
+func (s *source) Uint64() uint64 {
+	s.state += 0x9e3779b97f4a7c15
+
+	z := s.state
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+
+	return z ^ (z >> 31)
+}
+
+// Int63 returns the next pseudo-random non-negative 63 bit value
+func (s *source) Int63() int64 {
+	return int64(s.Uint64() >> 1)
+}
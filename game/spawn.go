@@ -0,0 +1,168 @@
+package game
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// spawnTwo and spawnFour are the exponents of newly placed tiles
+	spawnTwo  = 1
+	spawnFour = 2
+
+	// probFour is the chance that a newly placed tile is a 4
+	probFour = 0.1
+)
+
+// Spawn is a single tile placed on the board after a move
+// Value is the power of 2 of the tile, like the values in Game.Board
+type Spawn struct {
+	Row   uint64
+	Col   uint64
+	Value uint64
+}
+
+// SpawnOutcome is one possible result of placing new tiles after a move
+type SpawnOutcome struct {
+	// Spawns are the tiles placed, ordered by row then column
+	Spawns []Spawn
+
+	// Probability is the chance of this outcome
+	// The probabilities of all outcomes for a board add up to 1
+	Probability float64
+}
+
+// SpawnOutcomes enumerates every way the game could place its new tiles
+// on board, which should be a board just after a move
+// Each empty square is equally likely and every tile is a 2 or a 4
+func (g *Game) SpawnOutcomes(board [][]uint64) []SpawnOutcome {
+	return SpawnOutcomes(board, g.adds)
+}
+
+// SpawnOutcomes enumerates every way adds new tiles could be placed on board
+// Placing tiles stops when the board is full, so a full board has a single
+// outcome with no spawns
+// Different placement orders that produce the same board are combined
+// into a single outcome
+func SpawnOutcomes(board [][]uint64, adds uint64) []SpawnOutcome {
+	var empty []Spawn
+
+	for row := range board {
+		for col, val := range board[row] {
+			if val == 0 {
+				empty = append(empty, Spawn{Row: uint64(row), Col: uint64(col)})
+			}
+		}
+	}
+
+	outcomes := []SpawnOutcome{{Probability: 1}}
+
+	for i := uint64(0); i < adds; i++ {
+		var next []SpawnOutcome
+
+		// only needed when there is more than one tile to place,
+		// since that's when different orders can give the same board
+		seen := map[string]int{}
+
+		for _, out := range outcomes {
+			free := freeSquares(empty, out.Spawns)
+
+			if len(free) == 0 {
+				next = append(next, out)
+				continue
+			}
+
+			prob := out.Probability / float64(len(free))
+
+			for _, sq := range free {
+				for _, val := range []uint64{spawnTwo, spawnFour} {
+					spawn := sq
+					spawn.Value = val
+
+					p := prob * (1 - probFour)
+					if val == spawnFour {
+						p = prob * probFour
+					}
+
+					spawns := insertSpawn(out.Spawns, spawn)
+
+					if i == 0 {
+						next = append(next, SpawnOutcome{Spawns: spawns, Probability: p})
+						continue
+					}
+
+					key := spawnKey(spawns)
+					if idx, ok := seen[key]; ok {
+						next[idx].Probability += p
+						continue
+					}
+
+					seen[key] = len(next)
+					next = append(next, SpawnOutcome{Spawns: spawns, Probability: p})
+				}
+			}
+		}
+
+		outcomes = next
+	}
+
+	return outcomes
+}
+
+// freeSquares returns the empty squares that haven't been taken by spawns
+func freeSquares(empty, spawns []Spawn) []Spawn {
+	if len(spawns) == 0 {
+		return empty
+	}
+
+	free := make([]Spawn, 0, len(empty))
+
+	for _, sq := range empty {
+		taken := false
+
+		for _, sp := range spawns {
+			if sp.Row == sq.Row && sp.Col == sq.Col {
+				taken = true
+				break
+			}
+		}
+
+		if !taken {
+			free = append(free, sq)
+		}
+	}
+
+	return free
+}
+
+// insertSpawn returns a new slice with spawn added, keeping row then column order
+func insertSpawn(spawns []Spawn, spawn Spawn) []Spawn {
+	ret := make([]Spawn, len(spawns), len(spawns)+1)
+	copy(ret, spawns)
+	ret = append(ret, spawn)
+
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].Row != ret[j].Row {
+			return ret[i].Row < ret[j].Row
+		}
+		return ret[i].Col < ret[j].Col
+	})
+
+	return ret
+}
+
+func spawnKey(spawns []Spawn) string {
+	bdr := &strings.Builder{}
+
+	for _, sp := range spawns {
+		bdr.WriteString(strconv.FormatUint(sp.Row, 10))
+		bdr.WriteRune(',')
+		bdr.WriteString(strconv.FormatUint(sp.Col, 10))
+		bdr.WriteRune(',')
+		bdr.WriteString(strconv.FormatUint(sp.Value, 10))
+		bdr.WriteRune(';')
+	}
+
+	return bdr.String()
+}
@@ -0,0 +1,118 @@
+package game
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSpawnOutcomesSumToOne(t *testing.T) {
+	boards := map[string][][]uint64{
+		"empty":     {{0, 0, 0}, {0, 0, 0}},
+		"some free": {{1, 0, 2, 0}, {0, 3, 0, 1}, {2, 2, 0, 0}, {1, 1, 1, 1}},
+		"one free":  {{1, 2}, {3, 0}},
+		"full":      {{1, 2}, {3, 4}},
+	}
+
+	for name, board := range boards {
+		free := uint64(len(refEmpties(board)))
+
+		for adds := uint64(0); adds <= 3; adds++ {
+			outcomes := SpawnOutcomes(board, adds)
+
+			// placing stops when the board fills up
+			placed := adds
+			if free < placed {
+				placed = free
+			}
+
+			var total float64
+			for _, out := range outcomes {
+				total += out.Probability
+
+				if uint64(len(out.Spawns)) != placed {
+					t.Errorf("%v, %v adds: outcome %v places %v tiles, want %v", name, adds, out.Spawns, len(out.Spawns), placed)
+				}
+			}
+
+			if math.Abs(total-1) > 1e-9 {
+				t.Errorf("%v, %v adds: probabilities add up to %v", name, adds, total)
+			}
+		}
+	}
+}
+
+func TestSpawnOutcomesMerged(t *testing.T) {
+	board := [][]uint64{{0, 0, 0}, {0, 1, 0}}
+	outcomes := SpawnOutcomes(board, 2)
+
+	// every pair of the 5 free squares, with a 2 or 4 on each
+	if want := 5 * 4 / 2 * 4; len(outcomes) != want {
+		t.Fatalf("got %v outcomes, want %v", len(outcomes), want)
+	}
+
+	seen := map[string]bool{}
+	for _, out := range outcomes {
+		key := spawnKey(out.Spawns)
+		if seen[key] {
+			t.Fatalf("outcome %v listed twice", out.Spawns)
+		}
+		seen[key] = true
+
+		// either order of placing the two tiles gives this outcome
+		want := 2 * (1.0 / 5) * (1.0 / 4)
+		for _, sp := range out.Spawns {
+			if sp.Value == spawnFour {
+				want *= probFour
+			} else {
+				want *= 1 - probFour
+			}
+		}
+
+		if math.Abs(out.Probability-want) > 1e-12 {
+			t.Errorf("outcome %v has probability %v, want %v", out.Spawns, out.Probability, want)
+		}
+	}
+}
+
+// TestSpawnOutcomesMatchPlacing places tiles in many seeded games and
+// checks how often each outcome comes up
+func TestSpawnOutcomesMatchPlacing(t *testing.T) {
+	board := [][]uint64{{1, 0, 2, 0}, {0, 3, 3, 1}, {2, 1, 0, 4}, {1, 2, 3, 4}}
+	const games = 40000
+
+	for adds := uint64(1); adds <= 2; adds++ {
+		outcomes := SpawnOutcomes(board, adds)
+
+		counts := map[string]int{}
+		for seed := int64(0); seed < games; seed++ {
+			g, err := NewGameFromBoard(board, 11, adds, 0, seed)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var spawns []Spawn
+			for i := uint64(0); i < adds; i++ {
+				sp, _ := g.placeNew()
+				spawns = insertSpawn(spawns, sp)
+			}
+
+			counts[spawnKey(spawns)]++
+		}
+
+		listed := 0
+		for _, out := range outcomes {
+			key := spawnKey(out.Spawns)
+			listed += counts[key]
+
+			// well over 4 standard deviations for the most likely outcomes
+			got := float64(counts[key]) / games
+			if math.Abs(got-out.Probability) > 0.01 {
+				t.Errorf("%v adds: %v came up %.4f of the time, want %.4f", adds, out.Spawns, got, out.Probability)
+			}
+		}
+
+		if listed != games {
+			t.Errorf("%v adds: %v of %v games placed tiles no outcome lists", adds, games-listed, games)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package game
+
+// Square is a position on the board
+type Square struct {
+	Row uint64
+	Col uint64
+}
+
+// TileEventKind says what happened to a tile
+type TileEventKind byte
+
+const (
+	tileEventInvalid TileEventKind = iota
+
+	// TileSlide is a tile moving from one square to another
+	TileSlide
+
+	// TileMerge is two tiles that slid onto the same square combining into a new tile
+	TileMerge
+
+	// TileSpawn is a new tile placed after a move
+	TileSpawn
+)
+
+func (k TileEventKind) String() string {
+	switch k {
+	case TileSlide:
+		return "Slide"
+	case TileMerge:
+		return "Merge"
+	case TileSpawn:
+		return "Spawn"
+	default:
+		return "?unknown tile event?"
+	}
+}
+
+// TileEvent is something that happened to a tile during a move
+// Tiles have IDs that stay the same as they slide, starting from 1.
+// A merge makes a new tile with a new ID, after both merged tiles have
+// slid to its square.
+type TileEvent struct {
+	Kind TileEventKind
+
+	// Tile is the tile that slid or spawned, or the tile made by a merge
+	Tile uint64
+
+	// Merged are the two tiles combined by a merge, the first is the one
+	// that was nearer the edge moved towards
+	Merged [2]uint64
+
+	// From is where a sliding tile started, To is where a tile ended up
+	From Square
+	To   Square
+
+	// Value is the power of 2 of Tile, like the values in Board
+	Value uint64
+}
+
+// TrackTiles turns tile tracking on or off
+// While it's on, every tile has an ID and TileEvents reports what happened
+// to the tiles in the last move. Tracking is off by default, since it slows
+// moves down.
+func (g *Game) TrackTiles(on bool) {
+	if !on {
+		g.ids = nil
+		g.events = nil
+		return
+	}
+
+	if g.ids != nil {
+		return
+	}
+
+	g.ids = make([]uint64, g.width*g.height)
+
+	for row := uint64(0); row < g.height; row++ {
+		for col := uint64(0); col < g.width; col++ {
+			if g.eng.get(row, col) != 0 {
+				g.ids[row*g.width+col] = g.newTileID()
+			}
+		}
+	}
+}
+
+// TileIDs returns the ID of the tile in each square, 0 for empty squares
+// Returns nil unless tiles are being tracked
+func (g *Game) TileIDs() [][]uint64 {
+	if g.ids == nil {
+		return nil
+	}
+
+	ret := make([][]uint64, g.height)
+	for row := range ret {
+		start := uint64(row) * g.width
+		ret[row] = make([]uint64, g.width)
+		copy(ret[row], g.ids[start:start+g.width])
+	}
+
+	return ret
+}
+
+// TileEvents reports what happened to the tiles in the last move, with
+// slides first, then merges, then new tiles
+// Returns nil unless tiles are being tracked, or if the last move didn't
+// change the board.
+func (g *Game) TileEvents() []TileEvent {
+	return g.events
+}
+
+func (g *Game) newTileID() uint64 {
+	g.nextID++
+	return g.nextID
+}
+
+// trackMove works out the tile events for moving in dir and updates the
+// tile IDs to match. Must be called before the engine makes the move.
+func (g *Game) trackMove(dir Direction) []TileEvent {
+	var slides, merges []TileEvent
+	ids := make([]uint64, len(g.ids))
+
+	for _, tm := range planMove(g.eng, dir, g.width, g.height) {
+		id := g.ids[tm.from.Row*g.width+tm.from.Col]
+		to := tm.to.Row*g.width + tm.to.Col
+
+		if tm.from != tm.to {
+			slides = append(slides, TileEvent{Kind: TileSlide, Tile: id, From: tm.from, To: tm.to, Value: tm.value})
+		}
+
+		// the first of the pair is already at to
+		if tm.merged {
+			merged := g.newTileID()
+			merges = append(merges, TileEvent{Kind: TileMerge, Tile: merged, Merged: [2]uint64{ids[to], id}, To: tm.to, Value: tm.value + 1})
+			ids[to] = merged
+			continue
+		}
+
+		ids[to] = id
+	}
+
+	g.ids = ids
+
+	return append(slides, merges...)
+}
+
+// trackSpawn gives a new tile an ID and returns its event
+func (g *Game) trackSpawn(sp Spawn) TileEvent {
+	id := g.newTileID()
+	g.ids[sp.Row*g.width+sp.Col] = id
+
+	return TileEvent{Kind: TileSpawn, Tile: id, To: Square{sp.Row, sp.Col}, Value: sp.Value}
+}
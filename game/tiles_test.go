@@ -0,0 +1,158 @@
+package game
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTileEvents(t *testing.T) {
+	g, err := ParsePosition("1100/0000/0000/0020", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g.TrackTiles(true)
+
+	// tiles are numbered from 1 in row major order
+	ids := [][]uint64{{1, 2, 0, 0}, {0, 0, 0, 0}, {0, 0, 0, 0}, {0, 0, 3, 0}}
+	if got := g.TileIDs(); !reflect.DeepEqual(got, ids) {
+		t.Fatalf("got IDs %v, want %v", got, ids)
+	}
+
+	out, err := g.Play(DirLeft)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sp := out.Spawns[0]
+	want := []TileEvent{
+		{Kind: TileSlide, Tile: 2, From: Square{0, 1}, To: Square{0, 0}, Value: 1},
+		{Kind: TileSlide, Tile: 3, From: Square{3, 2}, To: Square{3, 0}, Value: 2},
+		{Kind: TileMerge, Tile: 4, Merged: [2]uint64{1, 2}, To: Square{0, 0}, Value: 2},
+		{Kind: TileSpawn, Tile: 5, To: Square{sp.Row, sp.Col}, Value: sp.Value},
+	}
+
+	if got := g.TileEvents(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got events %v, want %v", got, want)
+	}
+
+	ids = [][]uint64{{4, 0, 0, 0}, {0, 0, 0, 0}, {0, 0, 0, 0}, {3, 0, 0, 0}}
+	ids[sp.Row][sp.Col] = 5
+	if got := g.TileIDs(); !reflect.DeepEqual(got, ids) {
+		t.Fatalf("got IDs %v, want %v", got, ids)
+	}
+
+	// a move that changes nothing has no events
+	before := g.TileIDs()
+	for _, dir := range Directions {
+		if out, _ := g.Play(dir); !out.Moved {
+			if got := g.TileEvents(); got != nil {
+				t.Errorf("moving %v changed nothing but got events %v", dir, got)
+			}
+
+			if got := g.TileIDs(); !reflect.DeepEqual(got, before) {
+				t.Errorf("moving %v changed nothing but IDs went from %v to %v", dir, before, got)
+			}
+			break
+		}
+
+		before = g.TileIDs()
+	}
+}
+
+// TestTileEventsFollowBoard plays a whole game checking the events take the
+// IDs before each move to the IDs after it
+func TestTileEventsFollowBoard(t *testing.T) {
+	g := NewSeededGame(4, 4, 11, 1, 8)
+	g.TrackTiles(true)
+
+	for i := 0; g.State() == StatePlaying; i++ {
+		before := g.TileIDs()
+		board := g.Board()
+
+		g.Move(Directions[(i*7/3)%len(Directions)])
+
+		after := before
+		if events := g.TileEvents(); events != nil {
+			after = make([][]uint64, len(before))
+			for row := range after {
+				after[row] = make([]uint64, len(before[row]))
+			}
+
+			// tiles that don't slide stay put
+			slid := map[uint64]bool{}
+			for _, ev := range events {
+				if ev.Kind == TileSlide {
+					slid[ev.Tile] = true
+				}
+			}
+
+			for row := range before {
+				for col, id := range before[row] {
+					if !slid[id] {
+						after[row][col] = id
+					}
+				}
+			}
+
+			// events come slides first, then merges, then new tiles
+			for _, ev := range events {
+				switch ev.Kind {
+				case TileSlide:
+					if before[ev.From.Row][ev.From.Col] != ev.Tile || board[ev.From.Row][ev.From.Col] != ev.Value {
+						t.Fatalf("move %v: %v slides a tile that isn't there", i, ev)
+					}
+
+				case TileMerge:
+					// both tiles have slid onto the square, the second last
+					if after[ev.To.Row][ev.To.Col] != ev.Merged[1] {
+						t.Fatalf("move %v: %v merges a tile that isn't there", i, ev)
+					}
+
+				case TileSpawn:
+					if after[ev.To.Row][ev.To.Col] != 0 {
+						t.Fatalf("move %v: %v spawns on a tile", i, ev)
+					}
+				}
+
+				after[ev.To.Row][ev.To.Col] = ev.Tile
+			}
+		}
+
+		if got := g.TileIDs(); !reflect.DeepEqual(got, after) {
+			t.Fatalf("move %v: events %v take IDs %v to %v, but got %v", i, g.TileEvents(), before, after, got)
+		}
+
+		// every tile has an ID and empty squares don't
+		for row, vals := range g.Board() {
+			for col, val := range vals {
+				if (val == 0) != (after[row][col] == 0) {
+					t.Fatalf("move %v: square %v,%v holds %v with ID %v", i, row, col, val, after[row][col])
+				}
+			}
+		}
+	}
+}
+
+func TestTileIDsUndo(t *testing.T) {
+	g := NewSeededGame(4, 4, 11, 1, 4)
+	g.SetHistory(5)
+	g.TrackTiles(true)
+
+	before := g.TileIDs()
+	g.Move(DirLeft)
+	g.Move(DirDown)
+	after := g.TileIDs()
+
+	g.Undo()
+	g.Undo()
+	if got := g.TileIDs(); !reflect.DeepEqual(got, before) {
+		t.Errorf("undoing gave IDs %v, want %v", got, before)
+	}
+
+	g.Redo()
+	g.Redo()
+	if got := g.TileIDs(); !reflect.DeepEqual(got, after) {
+		t.Errorf("redoing gave IDs %v, want %v", got, after)
+	}
+}
@@ -0,0 +1,123 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrNotSquare is returned when exporting a board the web game can't show
+var ErrNotSquare = errors.New("game: the web game only has square boards")
+
+// webState is the gameState the original browser 2048 keeps in localStorage
+type webState struct {
+	Grid        webGrid `json:"grid"`
+	Score       uint64  `json:"score"`
+	Over        bool    `json:"over"`
+	Won         bool    `json:"won"`
+	KeepPlaying bool    `json:"keepPlaying"`
+}
+
+// webGrid holds the squares by column then row, cells[x][y], unlike Game.Board
+type webGrid struct {
+	Size  uint64       `json:"size"`
+	Cells [][]*webTile `json:"cells"`
+}
+
+// webTile holds the actual value of the tile, not the power of 2
+type webTile struct {
+	Position webPosition `json:"position"`
+	Value    uint64      `json:"value"`
+}
+
+type webPosition struct {
+	X uint64 `json:"x"`
+	Y uint64 `json:"y"`
+}
+
+// ImportWeb creates a game from the gameState JSON saved by the original
+// browser 2048. New tiles are placed from seed.
+// The web game keeps going after 2048 if keepPlaying is set, so use a
+// higher limitPower to carry on with those games.
+// Whether the game is won or lost comes from the board, not the JSON.
+func ImportWeb(data []byte, limitPower, adds uint64, seed int64) (*Game, error) {
+	var state webState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, err
+	}
+
+	size := state.Grid.Size
+	if size == 0 || uint64(len(state.Grid.Cells)) != size {
+		return nil, fmt.Errorf("game: web grid of size %v has %v columns", size, len(state.Grid.Cells))
+	}
+
+	board := make([][]uint64, size)
+	for y := range board {
+		board[y] = make([]uint64, size)
+	}
+
+	for x, col := range state.Grid.Cells {
+		if uint64(len(col)) != size {
+			return nil, fmt.Errorf("game: web grid of size %v has a column of %v", size, len(col))
+		}
+
+		for y, tile := range col {
+			if tile == nil {
+				continue
+			}
+
+			if tile.Position.X != uint64(x) || tile.Position.Y != uint64(y) {
+				return nil, fmt.Errorf("game: web tile at %v,%v says it is at %v,%v", x, y, tile.Position.X, tile.Position.Y)
+			}
+
+			// a tile with no value isn't a tile
+			power, err := valueToPower(tile.Value)
+			if err != nil {
+				return nil, err
+			}
+
+			board[y][x] = power
+		}
+	}
+
+	return NewGameFromBoard(board, limitPower, adds, state.Score, seed)
+}
+
+// ExportWeb writes the game as gameState JSON for the original browser 2048
+// Only square boards with tiles below 2^64 can be exported. Browsers lose
+// precision on tiles above 2^53.
+func (g *Game) ExportWeb() ([]byte, error) {
+	if g.width != g.height {
+		return nil, ErrNotSquare
+	}
+
+	board := g.Board()
+
+	cells := make([][]*webTile, g.width)
+	for x := range cells {
+		cells[x] = make([]*webTile, g.height)
+
+		for y := range cells[x] {
+			power := board[y][x]
+			if power == 0 {
+				continue
+			}
+
+			if power >= 64 {
+				return nil, fmt.Errorf("game: tile 2^%v is too large for the web game", power)
+			}
+
+			cells[x][y] = &webTile{
+				Position: webPosition{X: uint64(x), Y: uint64(y)},
+				Value:    uint64(1) << power,
+			}
+		}
+	}
+
+	return json.Marshal(webState{
+		Grid:  webGrid{Size: g.width, Cells: cells},
+		Score: g.score,
+		Over:  g.state == StateLost,
+		Won:   g.state == StateWon,
+	})
+}
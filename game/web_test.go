@@ -0,0 +1,114 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// webJSON is a 3x3 gameState as the browser saves it, columns first
+// The board is
+//
+//	2 . 8
+//	. 4 .
+//	. . 16
+const webJSON = `{
+	"grid": {"size": 3, "cells": [
+		[{"position": {"x": 0, "y": 0}, "value": 2}, null, null],
+		[null, {"position": {"x": 1, "y": 1}, "value": 4}, null],
+		[{"position": {"x": 2, "y": 0}, "value": 8}, null, {"position": {"x": 2, "y": 2}, "value": 16}]
+	]},
+	"score": 36, "over": false, "won": false, "keepPlaying": false
+}`
+
+func TestImportWeb(t *testing.T) {
+	g, err := ImportWeb([]byte(webJSON), 11, 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := [][]uint64{{1, 0, 3}, {0, 2, 0}, {0, 0, 4}}
+	if !reflect.DeepEqual(g.Board(), want) || g.Score() != 36 {
+		t.Fatalf("got %v scoring %v, want %v scoring 36", g.Board(), g.Score(), want)
+	}
+}
+
+func TestWebRoundTrip(t *testing.T) {
+	for _, pos := range []string{
+		"12/30 8",
+		"1230/0004/0500/6007 100",
+		"1234/2345/3456/4567 5000",
+		"b000/0000/0000/0001 20000",
+		"10000/02000/00300/00040/0000(40) 0 50",
+	} {
+		g, err := ParsePosition(pos, 1)
+		if err != nil {
+			t.Fatalf("%q: %v", pos, err)
+		}
+
+		data, err := g.ExportWeb()
+		if err != nil {
+			t.Fatalf("%q: %v", pos, err)
+		}
+
+		var state webState
+		if err := json.Unmarshal(data, &state); err != nil {
+			t.Fatalf("%q: %v", pos, err)
+		}
+
+		if state.Over != (g.State() == StateLost) || state.Won != (g.State() == StateWon) {
+			t.Errorf("%q: exported over %v and won %v in state %v", pos, state.Over, state.Won, g.State())
+		}
+
+		imported, err := ImportWeb(data, g.Limit(), g.Adds(), 1)
+		if err != nil {
+			t.Fatalf("%q: %v", pos, err)
+		}
+
+		if imported.Position() != g.Position() || imported.State() != g.State() {
+			t.Errorf("%q: imported as %q in state %v, want state %v", pos, imported.Position(), imported.State(), g.State())
+		}
+	}
+}
+
+func TestExportWebNotSquare(t *testing.T) {
+	g, err := ParsePosition("120/003", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := g.ExportWeb(); err != ErrNotSquare {
+		t.Errorf("got error %v, want %v", err, ErrNotSquare)
+	}
+}
+
+func TestImportWebErrors(t *testing.T) {
+	tile := func(x, y, value int) string {
+		return fmt.Sprintf(`{"position": {"x": %v, "y": %v}, "value": %v}`, x, y, value)
+	}
+
+	tests := []struct {
+		name  string
+		cells string
+		size  int
+	}{
+		{"no squares", `[]`, 0},
+		{"size doesn't match columns", `[[null, null], [null, null]]`, 3},
+		{"short column", `[[null, null], [null]]`, 2},
+		{"not a power of 2", `[[` + tile(0, 0, 6) + `, null], [null, null]]`, 2},
+		{"value 1", `[[` + tile(0, 0, 1) + `, null], [null, null]]`, 2},
+		{"wrong position", `[[null, ` + tile(1, 0, 2) + `], [null, null]]`, 2},
+	}
+
+	for _, tt := range tests {
+		data := fmt.Sprintf(`{"grid": {"size": %v, "cells": %v}, "score": 0}`, tt.size, tt.cells)
+		if g, err := ImportWeb([]byte(data), 11, 1, 1); err == nil {
+			t.Errorf("%v: got %v, want an error", tt.name, g.Board())
+		}
+	}
+
+	if _, err := ImportWeb([]byte(`{"grid": `), 11, 1, 1); err == nil {
+		t.Error("truncated JSON: got no error")
+	}
+}
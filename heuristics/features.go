@@ -0,0 +1,241 @@
+package heuristics
+
+import "math"
+
+// Empty counts the empty squares on the board
+func Empty(board [][]uint64) float64 {
+	var count float64
+
+	for _, row := range board {
+		for _, val := range row {
+			if val == 0 {
+				count++
+			}
+		}
+	}
+
+	return count
+}
+
+// Monotonicity penalizes rows and columns that aren't steadily increasing
+// or decreasing. The best board scores 0, everything else is negative.
+func Monotonicity(board [][]uint64) float64 {
+	height := len(board)
+	width := len(board[0])
+
+	// totals for increasing towards each direction
+	var up, down, left, right float64
+
+	for row := 0; row < height; row++ {
+		for col := 0; col < width-1; col++ {
+			cur := float64(board[row][col])
+			next := float64(board[row][col+1])
+
+			if cur > next {
+				right += next - cur
+			} else {
+				left += cur - next
+			}
+		}
+	}
+
+	for col := 0; col < width; col++ {
+		for row := 0; row < height-1; row++ {
+			cur := float64(board[row][col])
+			next := float64(board[row+1][col])
+
+			if cur > next {
+				down += next - cur
+			} else {
+				up += cur - next
+			}
+		}
+	}
+
+	return max(up, down) + max(left, right)
+}
+
+// Smoothness penalizes differences between neighbouring tiles, since
+// tiles of the same value next to each other can be combined
+// Empty squares are skipped
+func Smoothness(board [][]uint64) float64 {
+	height := len(board)
+	width := len(board[0])
+
+	var total float64
+
+	for row := 0; row < height; row++ {
+		for col := 0; col < width; col++ {
+			val := board[row][col]
+			if val == 0 {
+				continue
+			}
+
+			if col < width-1 && board[row][col+1] != 0 {
+				total -= diff(val, board[row][col+1])
+			}
+
+			if row < height-1 && board[row+1][col] != 0 {
+				total -= diff(val, board[row+1][col])
+			}
+		}
+	}
+
+	return total
+}
+
+// Corner gives the power of the largest tile when it is in a corner, 0 otherwise
+func Corner(board [][]uint64) float64 {
+	height := len(board)
+	width := len(board[0])
+
+	var largest uint64
+	for _, row := range board {
+		for _, val := range row {
+			if val > largest {
+				largest = val
+			}
+		}
+	}
+
+	corners := []uint64{
+		board[0][0],
+		board[0][width-1],
+		board[height-1][0],
+		board[height-1][width-1],
+	}
+
+	for _, val := range corners {
+		if val == largest {
+			return float64(largest)
+		}
+	}
+
+	return 0
+}
+
+// snakeRatio is how much each square along a snake counts compared to the
+// one before it
+const snakeRatio = 0.5
+
+// Snake rewards boards laid out along a snake, starting in a corner and
+// winding back and forth across the board, with the largest tiles first
+// Each square counts half as much as the one before it. Every corner and
+// both ways of winding are tried, and the best is used.
+func Snake(board [][]uint64) float64 {
+	height := len(board)
+	width := len(board[0])
+
+	best := math.Inf(-1)
+
+	for _, byRow := range []bool{true, false} {
+		for _, flipRow := range []bool{false, true} {
+			for _, flipCol := range []bool{false, true} {
+				var total float64
+				weight := 1.0
+
+				// lines are rows when winding by row, columns otherwise
+				lines, length := height, width
+				if !byRow {
+					lines, length = width, height
+				}
+
+				for l := 0; l < lines; l++ {
+					for i := 0; i < length; i++ {
+						// every other line winds back the other way
+						along := i
+						if l%2 == 1 {
+							along = length - 1 - i
+						}
+
+						row, col := l, along
+						if !byRow {
+							row, col = along, l
+						}
+
+						if flipRow {
+							row = height - 1 - row
+						}
+
+						if flipCol {
+							col = width - 1 - col
+						}
+
+						total += weight * float64(board[row][col])
+						weight *= snakeRatio
+					}
+				}
+
+				if total > best {
+					best = total
+				}
+			}
+		}
+	}
+
+	return best
+}
+
+// Merges counts the pairs of neighbouring tiles with the same value, which
+// could be combined by the next move
+// Empty squares in between are skipped, like they are when moving.
+func Merges(board [][]uint64) float64 {
+	height := len(board)
+	width := len(board[0])
+
+	var count float64
+
+	for row := 0; row < height; row++ {
+		var prev uint64
+		for col := 0; col < width; col++ {
+			val := board[row][col]
+			if val == 0 {
+				continue
+			}
+
+			// a tile only combines once, like in a move
+			if val == prev {
+				count++
+				prev = 0
+				continue
+			}
+			prev = val
+		}
+	}
+
+	for col := 0; col < width; col++ {
+		var prev uint64
+		for row := 0; row < height; row++ {
+			val := board[row][col]
+			if val == 0 {
+				continue
+			}
+
+			// a tile only combines once, like in a move
+			if val == prev {
+				count++
+				prev = 0
+				continue
+			}
+			prev = val
+		}
+	}
+
+	return count
+}
+
+func diff(a, b uint64) float64 {
+	if a > b {
+		return float64(a - b)
+	}
+
+	return float64(b - a)
+}
+
+func max(a, b float64) float64 {
+	if a > b {
+		return a
+	}
+
+	return b
+}
@@ -0,0 +1,78 @@
+package heuristics
+
+import "testing"
+
+var (
+	// the largest tiles along the top, increasing to a corner
+	topRow = [][]uint64{
+		{1, 2, 3, 4},
+		{0, 0, 0, 0},
+		{0, 0, 0, 0},
+		{0, 0, 0, 0},
+	}
+
+	// pairs to combine across the top and in the second row
+	pairs = [][]uint64{
+		{1, 1, 1, 1},
+		{2, 0, 2, 0},
+		{0, 0, 0, 0},
+		{0, 0, 0, 3},
+	}
+
+	// neither rows nor columns go the same way
+	crossed = [][]uint64{
+		{2, 1},
+		{1, 2},
+	}
+
+	// a board that isn't square
+	wide = [][]uint64{
+		{1, 0, 1},
+		{1, 2, 2},
+	}
+)
+
+func TestFeatures(t *testing.T) {
+	tests := []struct {
+		name  string
+		eval  Heuristic
+		board [][]uint64
+		want  float64
+	}{
+		{"empty", Empty, topRow, 12},
+		{"empty", Empty, pairs, 9},
+		{"empty", Empty, wide, 1},
+
+		{"monotonicity", Monotonicity, topRow, 0},
+		{"monotonicity", Monotonicity, crossed, -2},
+
+		{"smoothness", Smoothness, topRow, -3},
+		{"smoothness", Smoothness, pairs, -2},
+
+		{"corner", Corner, topRow, 4},
+		{"corner", Corner, pairs, 3},
+		{"corner", Corner, wide, 2},
+		{"corner", Corner, [][]uint64{{0, 1, 0}, {0, 5, 0}, {0, 0, 0}}, 0},
+
+		// best wound along the top row from the right
+		{"snake", Snake, topRow, 4 + 3*0.5 + 2*0.25 + 1*0.125},
+
+		{"merges", Merges, topRow, 0},
+		{"merges", Merges, pairs, 3},
+		{"merges", Merges, wide, 3},
+	}
+
+	for _, test := range tests {
+		if got := test.eval(test.board); got != test.want {
+			t.Errorf("%v of %v: got %v, want %v", test.name, test.board, got, test.want)
+		}
+	}
+}
+
+func TestWeighted(t *testing.T) {
+	h := Weighted(Weights{Empty: 2, Merges: 0.5})
+
+	if got, want := h(pairs), 2*9+0.5*3.0; got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,187 @@
+package heuristics
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Heuristic scores how good a board is, higher is better
+// The board holds the power of 2 in each square, like game.Game.Board
+type Heuristic func(board [][]uint64) float64
+
+// Weights sets how much each feature counts towards a Weighted heuristic
+// It can be used as a flag, set like "empty=2.7,snake=1". Features that
+// aren't named keep their weight.
+type Weights struct {
+	Empty        float64 `json:"empty"`
+	Monotonicity float64 `json:"monotonicity"`
+	Smoothness   float64 `json:"smoothness"`
+	Corner       float64 `json:"corner"`
+	Snake        float64 `json:"snake"`
+	Merges       float64 `json:"merges"`
+}
+
+// DefaultWeights are weights that do reasonably well on a 4x4 board
+var DefaultWeights = Weights{
+	Empty:        2.7,
+	Monotonicity: 1.0,
+	Smoothness:   0.1,
+	Corner:       1.0,
+}
+
+// Default is the heuristic used when none is given
+var Default = Weighted(DefaultWeights)
+
+// feature is a feature and where its weight is kept
+type feature struct {
+	name   string
+	eval   Heuristic
+	weight func(w *Weights) *float64
+}
+
+var features = []feature{
+	{"empty", Empty, func(w *Weights) *float64 { return &w.Empty }},
+	{"monotonicity", Monotonicity, func(w *Weights) *float64 { return &w.Monotonicity }},
+	{"smoothness", Smoothness, func(w *Weights) *float64 { return &w.Smoothness }},
+	{"corner", Corner, func(w *Weights) *float64 { return &w.Corner }},
+	{"snake", Snake, func(w *Weights) *float64 { return &w.Snake }},
+	{"merges", Merges, func(w *Weights) *float64 { return &w.Merges }},
+}
+
+// Names are the names of every feature, in the order Values uses
+func Names() []string {
+	ret := make([]string, len(features))
+	for i, f := range features {
+		ret[i] = f.name
+	}
+
+	return ret
+}
+
+// Weighted creates a heuristic that is the weighted sum of each feature
+// Features weighted 0 aren't worked out at all.
+func Weighted(w Weights) Heuristic {
+	var evals []Heuristic
+	var weights []float64
+
+	for _, f := range features {
+		if weight := *f.weight(&w); weight != 0 {
+			evals = append(evals, f.eval)
+			weights = append(weights, weight)
+		}
+	}
+
+	return func(board [][]uint64) float64 {
+		var total float64
+		for i, eval := range evals {
+			total += weights[i] * eval(board)
+		}
+
+		return total
+	}
+}
+
+// Values lists the weight of every feature, in the order of Names
+func (w Weights) Values() []float64 {
+	ret := make([]float64, len(features))
+	for i, f := range features {
+		ret[i] = *f.weight(&w)
+	}
+
+	return ret
+}
+
+// FromValues creates weights from a list in the order of Names
+// Extra values are ignored and missing ones are 0.
+func FromValues(vals []float64) Weights {
+	var w Weights
+	for i, f := range features {
+		if i < len(vals) {
+			*f.weight(&w) = vals[i]
+		}
+	}
+
+	return w
+}
+
+// String lists every weight like "empty=2.7,monotonicity=1", which Set reads back
+func (w *Weights) String() string {
+	if w == nil {
+		return ""
+	}
+
+	parts := make([]string, len(features))
+	for i, f := range features {
+		parts[i] = f.name + "=" + strconv.FormatFloat(*f.weight(w), 'g', -1, 64)
+	}
+
+	return strings.Join(parts, ",")
+}
+
+// Set changes the weights named in a comma separated list like "empty=2.7,snake=1"
+func (w *Weights) Set(s string) error {
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		name, val, ok := strings.Cut(part, "=")
+		if !ok {
+			return fmt.Errorf("heuristics: %q isn't name=weight", part)
+		}
+
+		weight, err := strconv.ParseFloat(strings.TrimSpace(val), 64)
+		if err != nil {
+			return fmt.Errorf("heuristics: bad weight for %v: %w", name, err)
+		}
+
+		found := false
+		for _, f := range features {
+			if f.name == strings.ToLower(strings.TrimSpace(name)) {
+				*f.weight(w) = weight
+				found = true
+			}
+		}
+
+		if !found {
+			return fmt.Errorf("heuristics: unknown feature %q", name)
+		}
+	}
+
+	return nil
+}
+
+// LoadWeights reads weights from a JSON file like {"empty": 2.7, "snake": 1}
+// Features that aren't in the file are weighted 0.
+func LoadWeights(name string) (Weights, error) {
+	var w Weights
+
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return w, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(&w); err != nil {
+		return Weights{}, fmt.Errorf("heuristics: %v: %w", name, err)
+	}
+
+	return w, nil
+}
+
+// SaveWeights writes w to a JSON file that LoadWeights can read
+func SaveWeights(name string, w Weights) error {
+	data, err := json.MarshalIndent(w, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(name, append(data, '\n'), 0644)
+}
@@ -0,0 +1,100 @@
+package heuristics
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWeightsSet(t *testing.T) {
+	w := DefaultWeights
+	if err := w.Set(" empty=2, Snake = 0.5,,"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := DefaultWeights
+	want.Empty = 2
+	want.Snake = 0.5
+
+	if w != want {
+		t.Errorf("got %v, want %v", w.String(), want.String())
+	}
+
+	// String has to read back the same
+	var read Weights
+	if err := read.Set(want.String()); err != nil {
+		t.Fatal(err)
+	}
+
+	if read != want {
+		t.Errorf("%q read back as %v", want.String(), read.String())
+	}
+
+	for _, s := range []string{"empty", "empty=", "empty=lots", "speed=1", "=1", "empty=1,speed=1"} {
+		w := DefaultWeights
+		if err := w.Set(s); err == nil {
+			t.Errorf("%q: got no error", s)
+		}
+	}
+}
+
+func TestValues(t *testing.T) {
+	w := Weights{Empty: 1, Monotonicity: 2, Smoothness: 3, Corner: 4, Snake: 5, Merges: 6}
+
+	if got := FromValues(w.Values()); got != w {
+		t.Errorf("got %v, want %v", got.String(), w.String())
+	}
+
+	if got, want := FromValues([]float64{7}), (Weights{Empty: 7}); got != want {
+		t.Errorf("from a single value got %v, want %v", got.String(), want.String())
+	}
+}
+
+func TestLoadWeights(t *testing.T) {
+	dir := t.TempDir()
+
+	write := func(name, data string) string {
+		t.Helper()
+
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		return path
+	}
+
+	// features that aren't there are 0, not their defaults
+	w, err := LoadWeights(write("some.json", `{"empty": 2.7, "snake": 1}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := (Weights{Empty: 2.7, Snake: 1}); w != want {
+		t.Errorf("got %v, want %v", w.String(), want.String())
+	}
+
+	saved := Weights{Empty: 1.5, Monotonicity: -2, Smoothness: 0.25, Corner: 3, Snake: 1e-3, Merges: 7}
+	path := filepath.Join(dir, "saved.json")
+	if err := SaveWeights(path, saved); err != nil {
+		t.Fatal(err)
+	}
+
+	if w, err := LoadWeights(path); err != nil || w != saved {
+		t.Errorf("saved %v, loaded %v with error %v", saved.String(), w.String(), err)
+	}
+
+	for name, data := range map[string]string{
+		"unknown.json": `{"empty": 1, "speed": 2}`,
+		"bad.json":     `{"empty": `,
+		"string.json":  `{"empty": "lots"}`,
+	} {
+		if _, err := LoadWeights(write(name, data)); err == nil {
+			t.Errorf("%v: got no error", name)
+		}
+	}
+
+	if _, err := LoadWeights(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("missing file: got no error")
+	}
+}
@@ -0,0 +1,157 @@
+package binio
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+)
+
+// Writer writes the compact binary formats used for saves, records and
+// weights, numbers as varints unless they say otherwise
+// Errors are kept until Flush.
+type Writer struct {
+	w   *bufio.Writer
+	buf [binary.MaxVarintLen64]byte
+}
+
+// NewWriter creates a writer that buffers its writes to w
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: bufio.NewWriter(w)}
+}
+
+// String writes s as it is, without its length
+func (w *Writer) String(s string) {
+	w.w.WriteString(s)
+}
+
+// Byte writes a single byte
+func (w *Writer) Byte(b byte) {
+	w.w.WriteByte(b)
+}
+
+// Uvarint writes x as an unsigned varint
+func (w *Writer) Uvarint(x uint64) {
+	n := binary.PutUvarint(w.buf[:], x)
+	w.w.Write(w.buf[:n])
+}
+
+// Varint writes x as a signed varint
+func (w *Writer) Varint(x int64) {
+	n := binary.PutVarint(w.buf[:], x)
+	w.w.Write(w.buf[:n])
+}
+
+// Uint32 writes x as 4 little endian bytes
+func (w *Writer) Uint32(x uint32) {
+	binary.LittleEndian.PutUint32(w.buf[:], x)
+	w.w.Write(w.buf[:4])
+}
+
+// Flush writes anything buffered and returns the first error
+func (w *Writer) Flush() error {
+	return w.w.Flush()
+}
+
+// Reader reads what a Writer writes
+// The first error sticks, every read after it returns 0.
+type Reader struct {
+	r     io.ByteReader
+	count uint64
+	err   error
+}
+
+// NewReader creates a reader from r, buffering it unless it can already
+// read a byte at a time
+func NewReader(r io.Reader) *Reader {
+	br, ok := r.(io.ByteReader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+
+	return &Reader{r: br}
+}
+
+// ReadByte reads a single byte, counting it
+func (r *Reader) ReadByte() (byte, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+
+	var b byte
+	b, r.err = r.r.ReadByte()
+	if r.err == nil {
+		r.count++
+	}
+
+	return b, r.err
+}
+
+// Magic reports whether the next bytes are magic
+func (r *Reader) Magic(magic string) bool {
+	for i := 0; i < len(magic); i++ {
+		if b, err := r.ReadByte(); err != nil || b != magic[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Byte reads a single byte
+func (r *Reader) Byte() byte {
+	b, _ := r.ReadByte()
+	return b
+}
+
+// Uvarint reads an unsigned varint
+func (r *Reader) Uvarint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+
+	x, err := binary.ReadUvarint(r)
+	if r.err == nil {
+		r.err = err
+	}
+
+	return x
+}
+
+// Varint reads a signed varint
+func (r *Reader) Varint() int64 {
+	if r.err != nil {
+		return 0
+	}
+
+	x, err := binary.ReadVarint(r)
+	if r.err == nil {
+		r.err = err
+	}
+
+	return x
+}
+
+// Uint32 reads 4 little endian bytes
+func (r *Reader) Uint32() uint32 {
+	var buf [4]byte
+	for i := range buf {
+		buf[i] = r.Byte()
+	}
+
+	return binary.LittleEndian.Uint32(buf[:])
+}
+
+// Count is how many bytes have been read
+func (r *Reader) Count() uint64 {
+	return r.count
+}
+
+// Err returns the first error, with running out part way through
+// reported as io.ErrUnexpectedEOF
+func (r *Reader) Err() error {
+	if r.err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+
+	return r.err
+}
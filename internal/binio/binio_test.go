@@ -0,0 +1,159 @@
+package binio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"strings"
+	"testing"
+)
+
+// write writes one of everything
+func write(t *testing.T) []byte {
+	t.Helper()
+
+	buf := &bytes.Buffer{}
+	w := NewWriter(buf)
+
+	w.String("magic")
+	w.Uvarint(0)
+	w.Uvarint(math.MaxUint64)
+	w.Varint(math.MinInt64)
+	w.Varint(-1)
+	w.Byte(0xab)
+	w.Uint32(0xdeadbeef)
+
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+// read reads what write writes, reporting the first thing read wrongly
+func read(r *Reader) string {
+	switch {
+	case !r.Magic("magic"):
+		return "magic"
+	case r.Uvarint() != 0:
+		return "uvarint 0"
+	case r.Uvarint() != math.MaxUint64:
+		return "uvarint max"
+	case r.Varint() != math.MinInt64:
+		return "varint min"
+	case r.Varint() != -1:
+		return "varint -1"
+	case r.Byte() != 0xab:
+		return "byte"
+	case r.Uint32() != 0xdeadbeef:
+		return "uint32"
+	}
+
+	return ""
+}
+
+func TestRoundTrip(t *testing.T) {
+	data := write(t)
+
+	// bytes.Reader reads a byte at a time itself, strings.Reader through
+	// a plain io.Reader needs buffering
+	for name, src := range map[string]io.Reader{
+		"byte reader": bytes.NewReader(data),
+		"reader":      struct{ io.Reader }{strings.NewReader(string(data))},
+	} {
+		r := NewReader(src)
+
+		if wrong := read(r); wrong != "" {
+			t.Errorf("%v: read %v wrongly", name, wrong)
+		}
+
+		if err := r.Err(); err != nil {
+			t.Errorf("%v: %v", name, err)
+		}
+
+		if r.Count() != uint64(len(data)) {
+			t.Errorf("%v: counted %v bytes, want %v", name, r.Count(), len(data))
+		}
+
+		// there's nothing left
+		r.Byte()
+		if err := r.Err(); err != io.ErrUnexpectedEOF {
+			t.Errorf("%v: reading past the end gave error %v, want %v", name, err, io.ErrUnexpectedEOF)
+		}
+	}
+}
+
+func TestTruncated(t *testing.T) {
+	data := write(t)
+
+	for n := len("magic"); n < len(data); n++ {
+		r := NewReader(bytes.NewReader(data[:n]))
+		read(r)
+
+		if err := r.Err(); err != io.ErrUnexpectedEOF {
+			t.Fatalf("reading %v of %v bytes: got error %v, want %v", n, len(data), err, io.ErrUnexpectedEOF)
+		}
+
+		if r.Count() != uint64(n) {
+			t.Fatalf("reading %v of %v bytes: counted %v", n, len(data), r.Count())
+		}
+	}
+}
+
+func TestMagic(t *testing.T) {
+	for _, s := range []string{"", "mag", "MAGIC", "magix"} {
+		if NewReader(strings.NewReader(s)).Magic("magic") {
+			t.Errorf("%q was taken for magic", s)
+		}
+	}
+
+	if !NewReader(strings.NewReader("magic and more")).Magic("magic") {
+		t.Error("magic at the start wasn't found")
+	}
+}
+
+// TestErrorSticks checks that nothing is read after the first error
+func TestErrorSticks(t *testing.T) {
+	// a varint with too many bytes, then a valid one
+	data := append(bytes.Repeat([]byte{0xff}, binary.MaxVarintLen64+1), 0x01, 0x02)
+
+	r := NewReader(bytes.NewReader(data))
+	r.Uvarint()
+
+	err := r.Err()
+	if err == nil || err == io.ErrUnexpectedEOF {
+		t.Fatalf("got error %v for an overlong varint", err)
+	}
+
+	if x := r.Uvarint(); x != 0 {
+		t.Errorf("read %v after an error, want 0", x)
+	}
+
+	if b := r.Byte(); b != 0 {
+		t.Errorf("read byte %v after an error, want 0", b)
+	}
+
+	if r.Err() != err {
+		t.Errorf("error changed from %v to %v", err, r.Err())
+	}
+}
+
+type failWriter struct{}
+
+var errWrite = errors.New("write failed")
+
+func (failWriter) Write([]byte) (int, error) {
+	return 0, errWrite
+}
+
+func TestWriterError(t *testing.T) {
+	w := NewWriter(failWriter{})
+	w.String(strings.Repeat("x", 10000))
+	w.Uvarint(1)
+
+	if err := w.Flush(); err != errWrite {
+		t.Errorf("got error %v, want %v", err, errWrite)
+	}
+}
@@ -1,44 +1,143 @@
 package main
 
 import (
+	"errors"
 	"flag"
+	"fmt"
+	"io"
 	"os"
 	"runtime/pprof"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/dystopium/2048/game"
+	"github.com/dystopium/2048/heuristics"
 	"github.com/dystopium/2048/players"
 	"github.com/dystopium/2048/players/console"
+	"github.com/dystopium/2048/players/expectimax"
 	"github.com/dystopium/2048/players/greedy"
+	"github.com/dystopium/2048/players/mcts"
+	"github.com/dystopium/2048/players/ntuple"
 	"github.com/dystopium/2048/players/random"
+	"github.com/dystopium/2048/replay"
+	"github.com/dystopium/2048/reporters"
+	"github.com/dystopium/2048/reporters/csv"
+	"github.com/dystopium/2048/reporters/jsonl"
+	"github.com/dystopium/2048/reporters/text"
 	"github.com/dystopium/2048/runners"
+	"github.com/dystopium/2048/runners/batch"
 	"github.com/dystopium/2048/runners/multiwin"
 	"github.com/dystopium/2048/runners/parallel"
 	"github.com/dystopium/2048/runners/single"
+	"github.com/dystopium/2048/runners/tournament"
+	"github.com/dystopium/2048/runners/train"
+	"github.com/dystopium/2048/runners/tune"
 	"github.com/dystopium/2048/runners/untilwin"
 )
 
 func main() {
 	var cpuprofilename string
 	var playerType string
+	var playerTypes string
 	var runnerType string
+	var reportType string
+	var reportFile string
+	var recordFile string
+	var replayFile string
+	var saveFile string
+	var position string
+	var webFile string
 	var width uint64
 	var height uint64
 	var limitPower uint64
 	var numAdds uint64
 	var numWins uint64
+	var numGames uint64
+	var seed int64
+	var depth uint64
+	var playouts uint64
+	var budget time.Duration
+	var heuristic string
+	var heuristicFile string
+	var weightsFile string
+	var tupleSize uint64
+	var alpha float64
+	var lambda float64
+	var episodes uint64
+	var logEvery uint64
+	var evalEvery uint64
+	var evalGames uint64
+	var checkpointEvery uint64
+	var trainLog string
+	var resume bool
+	var generations uint64
+	var population uint64
+	var tuneGames uint64
+	var sigma float64
+	var decay float64
+	var tuneOut string
 
 	flag.StringVar(&cpuprofilename, "cpuprofile", "", "File name for a CPU profile")
-	flag.StringVar(&playerType, "player", "console", "Player type. One of: console, random, greedy")
-	flag.StringVar(&runnerType, "runner", "untilwin", "Runner type. One of: single, untilwin, parallel, multiwin")
+	flag.StringVar(&playerType, "player", "console", "Player type. One of: console, random, greedy, expectimax, mcts, ntuple")
+	flag.StringVar(&runnerType, "runner", "untilwin", "Runner type. One of: single, untilwin, parallel, multiwin, batch, tournament, train, tune")
+	flag.StringVar(&playerTypes, "players", "random,greedy", "Comma separated player types to compare when using tournament runner")
+	flag.StringVar(&reportType, "report", "none", "Per game report format. One of: none, text, jsonl, csv")
+	flag.StringVar(&reportFile, "reportfile", "", "File name to write the report to. Standard output if not given.")
+	flag.StringVar(&recordFile, "record", "", "File name to record the last game started to. JSON if it ends in .json, binary otherwise. Most useful with single and untilwin runners.")
+	flag.StringVar(&replayFile, "replay", "", "File name of a recorded game to step through. Other flags are ignored.")
+	flag.StringVar(&saveFile, "savefile", console.DefaultSaveFile, "File name the console player saves to and loads from. JSON if it ends in .json, binary otherwise.")
+	flag.StringVar(&position, "position", "", "Start every game from this position, like \"1200/0000/0010/000b 1234 11 1\". Overrides -width, -height, -lim and -adds.")
+	flag.StringVar(&webFile, "webstate", "", "Start every game from the gameState JSON saved by the browser 2048 in this file. Overrides -width and -height.")
 	flag.Uint64Var(&width, "width", 4, "Width of the playing board.")
 	flag.Uint64Var(&height, "height", 4, "Height of the playing board.")
 	flag.Uint64Var(&limitPower, "lim", 11, "Power of 2 to set as the winning number. Default gives 2048.")
 	flag.Uint64Var(&numAdds, "adds", 1, "The number of random values to add after each move")
+	flag.Int64Var(&seed, "seed", 0, "Seed for the first game. Later games use the following seeds. Random if not given.")
+
+	flag.Uint64Var(&depth, "depth", 3, "Number of moves to look ahead when using expectimax player")
+	flag.StringVar(&heuristicFile, "heuristicfile", "", "JSON file of feature weights for the expectimax player, like {\"empty\": 2.7, \"snake\": 1}. Features not in it are weighted 0.")
+	flag.StringVar(&heuristic, "heuristic", "", "Feature weights for the expectimax player, like \"empty=2.7,snake=1\". Changes the defaults, or -heuristicfile if given. Features: "+strings.Join(heuristics.Names(), ", "))
+	flag.Uint64Var(&playouts, "playouts", 100, "Number of random games per direction when using mcts player")
+	flag.DurationVar(&budget, "budget", 0, "Time to spend on each move when using mcts player. Overrides -playouts if given.")
+	flag.StringVar(&weightsFile, "weights", "ntuple.weights", "File name of the ntuple player's weights. A new untrained network is used if it doesn't exist.")
+	flag.Uint64Var(&tupleSize, "tuples", 4, "Size of the tuples in a new ntuple network. One of: 4, 6")
+	flag.Float64Var(&alpha, "alpha", 0, "Learning rate for the ntuple player. If more than 0 it learns from every game and saves its weights at the end.")
+	flag.Float64Var(&lambda, "lambda", 0, "Lambda for the ntuple player's TD(lambda) learning. 0 for TD(0).")
 
 	flag.Uint64Var(&numWins, "numwins", 10, "Number of wins to get when using multiwin runner")
+	flag.Uint64Var(&numGames, "numgames", 1000, "Number of games to play when using batch runner, or per player when using tournament runner")
+
+	flag.Uint64Var(&episodes, "episodes", 100000, "Total number of games to learn from when using train runner")
+	flag.Uint64Var(&logEvery, "logevery", 1000, "Number of training games averaged in each line of the learning curve")
+	flag.Uint64Var(&evalEvery, "evalevery", 10000, "Number of training games between evaluations. Evaluation games use seeds from -seed on, and training games the seeds after them.")
+	flag.Uint64Var(&evalGames, "evalgames", 1000, "Number of games in each evaluation")
+	flag.Uint64Var(&checkpointEvery, "checkpointevery", 10000, "Number of training games between saving the weights to -weights")
+	flag.StringVar(&trainLog, "trainlog", "", "File name to write the learning curve to as CSV when using train runner")
+	flag.Uint64Var(&generations, "generations", 20, "Number of generations to breed when using tune runner")
+	flag.Uint64Var(&population, "population", 16, "Number of sets of weights in each generation when using tune runner")
+	flag.Uint64Var(&tuneGames, "tunegames", 20, "Number of games each set of weights plays when using tune runner. They use seeds from -seed on.")
+	flag.Float64Var(&sigma, "sigma", 0.5, "How far the tune runner moves weights at first, relative to their starting size")
+	flag.Float64Var(&decay, "decay", 0.9, "How much the tune runner shrinks -sigma by each generation")
+	flag.StringVar(&tuneOut, "tuneout", "heuristic.json", "File name the tune runner writes the best weights to, for use with -heuristicfile")
+	flag.BoolVar(&resume, "resume", false, "Carry on training from the last checkpoint of -weights, appending to -trainlog. Training always starts from -weights if it exists.")
 
 	flag.Parse()
 
+	if replayFile != "" {
+		rec, err := replay.Load(replayFile)
+		if err != nil {
+			panic(err)
+		}
+
+		if err := console.Replay(rec); err != nil {
+			panic(err)
+		}
+
+		return
+	}
+
 	if cpuprofilename != "" {
 		cpuprofile, err := os.Create(cpuprofilename)
 		if err != nil {
@@ -49,17 +148,120 @@ func main() {
 		defer pprof.StopCPUProfile()
 	}
 
-	var p players.Player
+	sgg := func(seed int64) *game.Game {
+		return game.NewSeededGame(width, height, limitPower, numAdds, seed)
+	}
+
+	if position != "" {
+		// check it once up front, so the game generators can't fail
+		if _, err := game.ParsePosition(position, 0); err != nil {
+			panic(err)
+		}
+
+		sgg = func(seed int64) *game.Game {
+			g, _ := game.ParsePosition(position, seed)
+			return g
+		}
+	}
+
+	if webFile != "" {
+		data, err := os.ReadFile(webFile)
+		if err != nil {
+			panic(err)
+		}
+
+		// check it once up front, so the game generators can't fail
+		if _, err := game.ImportWeb(data, limitPower, numAdds, 0); err != nil {
+			panic(err)
+		}
+
+		sgg = func(seed int64) *game.Game {
+			g, _ := game.ImportWeb(data, limitPower, numAdds, seed)
+			return g
+		}
+	}
+
+	// the ntuple network is shared by every agent, and loaded when first needed
+	// It's sized from the board games start on, which -position or -webstate
+	// can change from -width and -height.
+	start := sgg(0)
+	var network *ntuple.Network
+	loadNetwork := func() *ntuple.Network {
+		if network != nil {
+			return network
+		}
+
+		var err error
+		network, err = ntuple.Load(weightsFile)
+
+		if errors.Is(err, os.ErrNotExist) {
+			tuples := ntuple.FourTuples
+			if tupleSize == 6 {
+				tuples = ntuple.SixTuples
+			}
+
+			network, err = ntuple.NewNetwork(start.Width(), start.Height(), tuples)
+		}
+
+		if err != nil {
+			panic(err)
+		}
+
+		if network.Width() != start.Width() || network.Height() != start.Height() {
+			panic(fmt.Errorf("the weights in %v are for a %vx%v board, not %vx%v", weightsFile, network.Width(), network.Height(), start.Width(), start.Height()))
+		}
+
+		return network
+	}
+
+	weights := heuristics.DefaultWeights
+
+	if heuristicFile != "" {
+		var err error
+		if weights, err = heuristics.LoadWeights(heuristicFile); err != nil {
+			panic(err)
+		}
+	}
+
+	if err := weights.Set(heuristic); err != nil {
+		panic(err)
+	}
+
+	h := heuristics.Weighted(weights)
+
+	newPlayer := func(playerType string) players.AgentGen {
+		switch playerType {
+		case "console":
+			return func() players.Agent { return console.New(saveFile) }
+
+		case "random":
+			return random.New
+
+		case "greedy":
+			return greedy.New
+
+		case "expectimax":
+			return func() players.Agent { return expectimax.New(depth, h) }
 
-	switch playerType {
-	case "console":
-		p = console.Play
+		case "mcts":
+			return func() players.Agent { return mcts.New(playouts, budget) }
 
-	case "random":
-		p = random.Play
+		case "ntuple":
+			n := loadNetwork()
 
-	case "greedy":
-		p = greedy.Play
+			if alpha > 0 {
+				return func() players.Agent { return ntuple.NewLearner(n, alpha, lambda) }
+			}
+
+			return func() players.Agent { return ntuple.New(n) }
+		}
+
+		return nil
+	}
+
+	p := newPlayer(playerType)
+	if p == nil {
+		usageError("unknown player type %q", playerType)
 	}
 
 	var runner runners.Runner
@@ -76,11 +278,221 @@ func main() {
 
 	case "multiwin":
 		runner = multiwin.New(numWins)
+
+	case "batch":
+		runner = batch.New(numGames)
+	}
+
+	var out io.Writer = os.Stdout
+
+	if reportFile != "" {
+		f, err := os.Create(reportFile)
+		if err != nil {
+			panic(err)
+		}
+		defer f.Close()
+
+		out = f
 	}
 
+	var rep reporters.Reporter
+
+	switch reportType {
+	case "none":
+		rep = reporters.Discard
+
+	case "text":
+		rep = text.New(out)
+
+	case "jsonl":
+		rep = jsonl.New(out)
+
+	case "csv":
+		rep = csv.New(out)
+	}
+
+	seeded := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "seed" {
+			seeded = true
+		}
+	})
+
 	gg := func() *game.Game {
-		return game.NewGame(width, height, limitPower, numAdds)
+		return sgg(game.NewSeed())
+	}
+
+	// how many training games were played before the last checkpoint
+	var trained uint64
+	if runnerType == "train" && resume {
+		var err error
+		if trained, err = train.Resume(weightsFile); err != nil {
+			panic(err)
+		}
+	}
+
+	if seeded {
+		// runners like parallel call this from many goroutines
+		next := seed - 1
+
+		// training games use the seeds after the evaluation games, carrying
+		// on from the last checkpoint when resuming
+		if runnerType == "train" {
+			next += int64(evalGames + trained)
+		}
+
+		gg = func() *game.Game {
+			return sgg(atomic.AddInt64(&next, 1))
+		}
+	}
+
+	var recMu sync.Mutex
+	var lastRec *replay.Recorder
+
+	if recordFile != "" {
+		unrecorded := gg
+		gg = func() *game.Game {
+			g := unrecorded()
+
+			rec, err := replay.NewRecorder(g)
+			if err != nil {
+				panic(err)
+			}
+
+			recMu.Lock()
+			lastRec = rec
+			recMu.Unlock()
+
+			return g
+		}
 	}
 
-	runner(gg, p)
+	if runnerType == "tournament" {
+		if !seeded {
+			seed = game.NewSeed()
+		}
+
+		var entries []tournament.Entry
+		for _, name := range strings.Split(playerTypes, ",") {
+			name = strings.TrimSpace(name)
+
+			ag := newPlayer(name)
+			if ag == nil {
+				usageError("unknown player type %q in -players", name)
+			}
+
+			entries = append(entries, tournament.Entry{Name: name, Agent: ag})
+		}
+
+		if err := tournament.Run(sgg, entries, seed, numGames, rep); err != nil {
+			panic(err)
+		}
+
+	} else if runnerType == "train" {
+		if playerType != "ntuple" {
+			panic("the train runner needs a learning player, like ntuple")
+		}
+
+		n := loadNetwork()
+
+		rate := alpha
+		if rate == 0 {
+			rate = ntuple.DefaultAlpha
+		}
+
+		cfg := train.Config{
+			Episodes:        episodes,
+			LogEvery:        logEvery,
+			EvalEvery:       evalEvery,
+			EvalGames:       evalGames,
+			EvalSeed:        seed,
+			CheckpointEvery: checkpointEvery,
+			Checkpoint:      weightsFile,
+			Done:            trained,
+		}
+
+		logFlags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+		if resume {
+			logFlags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+		}
+
+		if trainLog != "" {
+			f, err := os.OpenFile(trainLog, logFlags, 0644)
+			if err != nil {
+				panic(err)
+			}
+			defer f.Close()
+
+			cfg.Log = f
+		}
+
+		l := train.Learner{
+			Learn: func() players.Agent { return ntuple.NewLearner(n, rate, lambda) },
+			Play:  func() players.Agent { return ntuple.New(n) },
+			Save:  func(name string) error { return ntuple.Save(name, n) },
+		}
+
+		if err := train.Run(gg, sgg, l, cfg); err != nil {
+			panic(err)
+		}
+
+	} else if runnerType == "tune" {
+		if playerType != "expectimax" {
+			panic("the tune runner needs a player with a heuristic, like expectimax")
+		}
+
+		cfg := tune.Config{
+			Generations: generations,
+			Population:  population,
+			Games:       tuneGames,
+			FirstSeed:   seed,
+			Sigma:       sigma,
+			Decay:       decay,
+			Seed:        seed,
+			Out:         tuneOut,
+		}
+
+		player := func(w heuristics.Weights) players.AgentGen {
+			h := heuristics.Weighted(w)
+			return func() players.Agent { return expectimax.New(depth, h) }
+		}
+
+		best, err := tune.Run(sgg, player, weights, cfg)
+		if err != nil {
+			panic(err)
+		}
+
+		if tuneOut != "" {
+			fmt.Printf("\nBest weights written to %v: %v\n", tuneOut, &best)
+		} else {
+			fmt.Printf("\nBest weights: %v\n", &best)
+		}
+
+	} else if err := runner(gg, p, rep); err != nil {
+		panic(err)
+	}
+
+	if err := rep.Flush(); err != nil {
+		panic(err)
+	}
+
+	// training saves its own checkpoints
+	if network != nil && alpha > 0 && runnerType != "train" {
+		if err := ntuple.Save(weightsFile, network); err != nil {
+			panic(err)
+		}
+	}
+
+	if lastRec != nil {
+		if err := replay.Save(recordFile, lastRec.Record()); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// usageError reports a bad flag value along with the usage and exits
+func usageError(format string, args ...interface{}) {
+	fmt.Fprintf(flag.CommandLine.Output(), format+"\n", args...)
+	flag.Usage()
+	os.Exit(2)
 }
@@ -0,0 +1,60 @@
+package players
+
+import (
+	"fmt"
+
+	"github.com/dystopium/2048/game"
+)
+
+// maxIdle is how many moves in a row can leave the board unchanged before
+// an agent is considered stuck
+const maxIdle = 1000
+
+// ErrStuck is returned when an agent keeps choosing moves that do nothing
+var ErrStuck = fmt.Errorf("players: agent made %v moves in a row without changing the board", maxIdle)
+
+// Run plays g with a until it's won or lost
+// The game can also end while a is choosing, by undoing, redoing or loading.
+// Returns an error if a chooses something that isn't a direction or gets stuck
+func Run(g *game.Game, a Agent) error {
+	a.Reset(g)
+
+	obs, _ := a.(Observer)
+	var idle uint64
+
+	for g.State() == game.StatePlaying {
+		dir := a.ChooseMove(g)
+
+		// choosing may have replaced the game with one that's over
+		if g.State() != game.StatePlaying {
+			return nil
+		}
+
+		out, err := g.Play(dir)
+		if err != nil {
+			return err
+		}
+
+		if obs != nil {
+			obs.Observe(dir, out)
+		}
+
+		if out.Moved {
+			idle = 0
+		} else if idle++; idle >= maxIdle {
+			return ErrStuck
+		}
+	}
+
+	return nil
+}
+
+// Func turns agents into a Player that owns the game loop
+// A new agent is created for every game. Panics if it fails to play.
+func Func(gen AgentGen) Player {
+	return func(g *game.Game) {
+		if err := Run(g, gen()); err != nil {
+			panic(err)
+		}
+	}
+}
@@ -3,31 +3,75 @@ package console
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/dystopium/2048/game"
+	"github.com/dystopium/2048/players"
+	"github.com/dystopium/2048/replay"
 )
 
-// Play allows the human to play
+// history is how many moves a human can undo
+const history = 1000
+
+// DefaultSaveFile is where Play saves and loads games
+const DefaultSaveFile = "2048.save"
+
+// Play allows the human to play, saving and loading with DefaultSaveFile
 func Play(g *game.Game) {
-	input := bufio.NewReader(os.Stdin)
+	players.Func(func() players.Agent { return New(DefaultSaveFile) })(g)
+}
+
+// New creates an agent that allows the human to play
+// Games are saved to and loaded from saveFile, as JSON if it ends in .json
+// and in a binary format otherwise
+func New(saveFile string) players.Agent {
+	return &agent{
+		saveFile: saveFile,
+		input:    bufio.NewReader(os.Stdin),
+	}
+}
 
-	fmt.Println("Use IJKL for Up Left Down Right")
+type agent struct {
+	saveFile string
+	input    *bufio.Reader
 
-	print := true
+	// g is the game being played, to show how it ended
+	g *game.Game
+
+	// print is whether to show the board before the next key
+	print bool
+}
+
+func (a *agent) Reset(g *game.Game) {
+	fmt.Println("Use IJKL for Up Left Down Right, U to undo and R to redo")
+	fmt.Printf("Use S to save to and O to load from %v\n", a.saveFile)
+
+	g.SetHistory(history)
 
+	a.g = g
+	a.print = true
+}
+
+// ChooseMove reads keys until one is a move
+// Undoing, redoing, saving and loading happen along the way.
+func (a *agent) ChooseMove(g *game.Game) game.Direction {
 	for {
-		if print {
+		if a.print {
 			fmt.Printf("\nScore: %v\tMoves: %v\n\n", g.Score(), g.TotalMoves())
 			fmt.Println(g)
 		}
-		print = true
+		a.print = true
 
+		// a loaded game might already be over
 		if g.State() != game.StatePlaying {
-			break
+			a.finish()
+			return game.DirUp
 		}
 
-		cmd, _, err := input.ReadRune()
+		cmd, _, err := a.input.ReadRune()
 		if err != nil {
 			panic(err)
 		}
@@ -36,29 +80,75 @@ func Play(g *game.Game) {
 		case 'i':
 			fallthrough
 		case 'I':
-			g.Move(game.DirUp)
+			return game.DirUp
 
 		case 'k':
 			fallthrough
 		case 'K':
-			g.Move(game.DirDown)
+			return game.DirDown
 
 		case 'j':
 			fallthrough
 		case 'J':
-			g.Move(game.DirLeft)
+			return game.DirLeft
 
 		case 'l':
 			fallthrough
 		case 'L':
-			g.Move(game.DirRight)
+			return game.DirRight
+
+		case 'u':
+			fallthrough
+		case 'U':
+			if !g.Undo() {
+				fmt.Println("Nothing to undo")
+				a.print = false
+			}
+
+		case 'r':
+			fallthrough
+		case 'R':
+			if !g.Redo() {
+				fmt.Println("Nothing to redo")
+				a.print = false
+			}
+
+		case 's':
+			fallthrough
+		case 'S':
+			if err := save(g, a.saveFile); err != nil {
+				fmt.Println("Saving failed:", err)
+			} else {
+				fmt.Println("Saved to", a.saveFile)
+			}
+			a.print = false
+
+		case 'o':
+			fallthrough
+		case 'O':
+			if err := load(g, a.saveFile); err != nil {
+				fmt.Println("Loading failed:", err)
+				a.print = false
+			}
 
 		case '\n':
-			print = false
+			a.print = false
 		}
 	}
+}
+
+func (a *agent) Observe(dir game.Direction, out game.Outcome) {
+	if out.State == game.StatePlaying {
+		return
+	}
 
-	switch g.State() {
+	fmt.Printf("\nScore: %v\tMoves: %v\n\n", a.g.Score(), a.g.TotalMoves())
+	fmt.Println(a.g)
+	a.finish()
+}
+
+func (a *agent) finish() {
+	switch a.g.State() {
 	case game.StateLost:
 		fmt.Println("YOU LOST")
 
@@ -66,3 +156,128 @@ func Play(g *game.Game) {
 		fmt.Println("YOU WON")
 	}
 }
+
+func save(g *game.Game, name string) error {
+	var data []byte
+	var err error
+
+	if isJSON(name) {
+		data, err = g.MarshalJSON()
+	} else {
+		data, err = g.MarshalBinary()
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(name, data, 0644)
+}
+
+func load(g *game.Game, name string) error {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return err
+	}
+
+	if isJSON(name) {
+		return g.UnmarshalJSON(data)
+	}
+
+	return g.UnmarshalBinary(data)
+}
+
+func isJSON(name string) bool {
+	return strings.EqualFold(filepath.Ext(name), ".json")
+}
+
+// Replay steps through a recorded game, forwards and backwards
+func Replay(rec *replay.Record) error {
+	r, err := replay.NewReplayer(rec)
+	if err != nil {
+		return err
+	}
+
+	input := bufio.NewReader(os.Stdin)
+
+	fmt.Println("Use L for next move, J for previous move, Q to quit")
+
+	// boards[i] is the game after i steps, kept so stepping back is cheap
+	boards := []*game.Game{r.Game().Clone()}
+	cur := 0
+
+	print := true
+
+	for {
+		if print {
+			printStep(boards[cur], rec, cur)
+		}
+		print = true
+
+		cmd, _, err := input.ReadRune()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		switch cmd {
+		case 'l':
+			fallthrough
+		case 'L':
+			if cur+1 < len(boards) {
+				cur++
+				break
+			}
+
+			if _, err := r.Next(); err == io.EOF {
+				fmt.Println("\nEnd of the recording")
+				print = false
+				break
+			} else if err != nil {
+				return err
+			}
+
+			boards = append(boards, r.Game().Clone())
+			cur++
+
+		case 'j':
+			fallthrough
+		case 'J':
+			if cur == 0 {
+				fmt.Println("\nStart of the recording")
+				print = false
+				break
+			}
+
+			cur--
+
+		case 'q':
+			fallthrough
+		case 'Q':
+			return nil
+
+		default:
+			print = false
+		}
+	}
+}
+
+// printStep shows the game after step moves of rec
+func printStep(g *game.Game, rec *replay.Record, step int) {
+	fmt.Printf("\nMove %v of %v\tScore: %v\tState: %v\n", step, len(rec.Steps), g.Score(), g.State())
+
+	if step > 0 {
+		played := rec.Steps[step-1]
+		fmt.Printf("Played %v", played.Dir)
+
+		for _, sp := range played.Spawns {
+			fmt.Printf(", placed %v at row %v column %v", uint64(1)<<sp.Value, sp.Row+1, sp.Col+1)
+		}
+
+		fmt.Println()
+	}
+
+	fmt.Println()
+	fmt.Println(g)
+}
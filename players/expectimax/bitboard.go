@@ -0,0 +1,93 @@
+package expectimax
+
+import (
+	"math"
+
+	"github.com/dystopium/2048/game"
+)
+
+// The search on 4x4 boards with one new tile a move works on game.Bitboard
+// values, so looking ahead doesn't allocate. It scores boards exactly like
+// the search on [][]uint64 boards does.
+
+// bestBits is best for a board packed into a Bitboard
+func (s *search) bestBits(b game.Bitboard, depth uint64) (game.Direction, bool) {
+	var bestDir game.Direction
+	bestVal := math.Inf(-1)
+	found := false
+
+	for _, dir := range game.Directions {
+		next, _, moved := b.Move(dir)
+		if !moved {
+			continue
+		}
+
+		val := s.chanceBits(next, depth-1, 1)
+		if val > bestVal {
+			bestVal = val
+			bestDir = dir
+			found = true
+		}
+	}
+
+	return bestDir, found
+}
+
+// maxBits is max for a board packed into a Bitboard
+func (s *search) maxBits(b game.Bitboard, depth uint64, prob float64) float64 {
+	best := math.Inf(-1)
+
+	for _, dir := range game.Directions {
+		next, _, moved := b.Move(dir)
+		if !moved {
+			continue
+		}
+
+		val := s.chanceBits(next, depth-1, prob)
+		if val > best {
+			best = val
+		}
+	}
+
+	// the game is lost
+	if math.IsInf(best, -1) {
+		return s.eval(b) - lossPenalty(s.scratch)
+	}
+
+	return best
+}
+
+// chanceBits is chance for a board packed into a Bitboard, when one tile
+// is placed after each move
+func (s *search) chanceBits(b game.Bitboard, depth uint64, prob float64) float64 {
+	if depth == 0 || prob < minProbability {
+		return s.eval(b)
+	}
+
+	// a full board can only be moved
+	if b.Empties() == 0 {
+		return s.maxBits(b, depth, prob)
+	}
+
+	var total float64
+
+	b.Spawns(func(next game.Bitboard, p float64) {
+		total += p * s.maxBits(next, depth, prob*p)
+	})
+
+	return total
+}
+
+// eval scores a board with the heuristic, remembering boards already scored
+// for the rest of the move
+func (s *search) eval(b game.Bitboard) float64 {
+	if val, ok := s.scores[b]; ok {
+		return val
+	}
+
+	b.Fill(s.scratch)
+	val := s.h(s.scratch)
+	s.scores[b] = val
+
+	return val
+}
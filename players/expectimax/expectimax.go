@@ -0,0 +1,159 @@
+package expectimax
+
+import (
+	"math"
+
+	"github.com/dystopium/2048/game"
+	"github.com/dystopium/2048/heuristics"
+	"github.com/dystopium/2048/players"
+)
+
+// minProbability is the chance below which a branch isn't worth searching
+// and is scored by the heuristic instead
+const minProbability = 0.0001
+
+// New creates an agent that looks depth moves ahead, assuming the best move
+// is played each turn and averaging over every tile that could be placed
+// Boards at the end of the search are scored with h, or heuristics.Default
+// if h is nil
+func New(depth uint64, h heuristics.Heuristic) players.Agent {
+	if depth == 0 {
+		depth = 1
+	}
+
+	if h == nil {
+		h = heuristics.Default
+	}
+
+	scratch := make([][]uint64, 4)
+	for row := range scratch {
+		scratch[row] = make([]uint64, 4)
+	}
+
+	return &search{h: h, depth: depth, scratch: scratch, scores: map[game.Bitboard]float64{}}
+}
+
+type search struct {
+	g     *game.Game
+	h     heuristics.Heuristic
+	depth uint64
+
+	// scratch is a 4x4 board for scoring Bitboards with h
+	scratch [][]uint64
+
+	// scores are the boards scored by h so far this move
+	scores map[game.Bitboard]float64
+}
+
+func (s *search) Reset(g *game.Game) {
+	s.g = g
+}
+
+func (s *search) ChooseMove(g *game.Game) game.Direction {
+	s.g = g
+	board := g.Board()
+
+	var dir game.Direction
+	var ok bool
+
+	if b, fits := game.NewBitboard(board); fits && g.Adds() == 1 {
+		for k := range s.scores {
+			delete(s.scores, k)
+		}
+
+		dir, ok = s.bestBits(b, s.depth)
+	} else {
+		dir, ok = s.best(board, s.depth)
+	}
+
+	// nothing can move, any move will end the game
+	if !ok {
+		return game.DirUp
+	}
+
+	return dir
+}
+
+// best finds the direction with the highest expected value
+// Returns false if no direction moves anything
+func (s *search) best(board [][]uint64, depth uint64) (game.Direction, bool) {
+	var bestDir game.Direction
+	bestVal := math.Inf(-1)
+	found := false
+
+	for _, dir := range game.Directions {
+		next, _, moved := game.Slide(board, dir)
+		if !moved {
+			continue
+		}
+
+		val := s.chance(next, depth-1, 1)
+		if val > bestVal {
+			bestVal = val
+			bestDir = dir
+			found = true
+		}
+	}
+
+	return bestDir, found
+}
+
+// max scores a board where it's the player's turn to move
+func (s *search) max(board [][]uint64, depth uint64, prob float64) float64 {
+	best := math.Inf(-1)
+
+	for _, dir := range game.Directions {
+		next, _, moved := game.Slide(board, dir)
+		if !moved {
+			continue
+		}
+
+		val := s.chance(next, depth-1, prob)
+		if val > best {
+			best = val
+		}
+	}
+
+	// the game is lost
+	if math.IsInf(best, -1) {
+		return s.h(board) - lossPenalty(board)
+	}
+
+	return best
+}
+
+// chance scores a board just after a move by averaging over where new tiles land
+func (s *search) chance(board [][]uint64, depth uint64, prob float64) float64 {
+	if depth == 0 || prob < minProbability {
+		return s.h(board)
+	}
+
+	var total float64
+
+	for _, out := range s.g.SpawnOutcomes(board) {
+		next := place(board, out.Spawns)
+		total += out.Probability * s.max(next, depth, prob*out.Probability)
+	}
+
+	return total
+}
+
+// lossPenalty is large enough that losing is always worse than not losing
+func lossPenalty(board [][]uint64) float64 {
+	return float64(len(board)*len(board[0])) * 1000
+}
+
+// place returns a copy of board with the spawned tiles added
+func place(board [][]uint64, spawns []game.Spawn) [][]uint64 {
+	ret := make([][]uint64, len(board))
+	for i, row := range board {
+		ret[i] = make([]uint64, len(row))
+		copy(ret[i], row)
+	}
+
+	for _, sp := range spawns {
+		ret[sp.Row][sp.Col] = sp.Value
+	}
+
+	return ret
+}
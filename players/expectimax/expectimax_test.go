@@ -0,0 +1,35 @@
+package expectimax
+
+import (
+	"testing"
+
+	"github.com/dystopium/2048/game"
+)
+
+// TestBitboardSearch checks that searching on Bitboards picks the same
+// moves as searching on [][]uint64 boards
+func TestBitboardSearch(t *testing.T) {
+	for seed := int64(0); seed < 5; seed++ {
+		g := game.NewSeededGame(4, 4, 11, 1, seed)
+		s := New(2, nil).(*search)
+		s.Reset(g)
+
+		for i := 0; i < 60 && g.State() == game.StatePlaying; i++ {
+			board := g.Board()
+
+			b, ok := game.NewBitboard(board)
+			if !ok {
+				t.Fatalf("couldn't pack %v", board)
+			}
+
+			dir, found := s.best(board, s.depth)
+			bitsDir, bitsFound := s.bestBits(b, s.depth)
+
+			if dir != bitsDir || found != bitsFound {
+				t.Fatalf("seed %v, move %v: chose %v on Bitboards, want %v\n%v", seed, i, bitsDir, dir, g)
+			}
+
+			g.Move(s.ChooseMove(g))
+		}
+	}
+}
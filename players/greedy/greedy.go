@@ -7,6 +7,8 @@ import (
 	"math/rand"
 
 	"github.com/dystopium/2048/game"
+	"github.com/dystopium/2048/players"
+	"github.com/dystopium/2048/players/random"
 )
 
 func newRand() *rand.Rand {
@@ -18,134 +20,155 @@ func newRand() *rand.Rand {
 
 // Play allows the human to play
 func Play(g *game.Game) {
-	rnd := newRand()
+	players.Func(New)(g)
+}
+
+// New creates an agent that makes whichever move combines the most tiles,
+// and a random one when nothing combines
+func New() players.Agent {
+	return &agent{
+		rnd:      newRand(),
+		fallback: random.New().(players.Observer),
+	}
+}
+
+type agent struct {
+	rnd      *rand.Rand
+	fallback players.Observer
+}
 
-	for g.State() == game.StatePlaying {
-		var dirs []game.Direction
+func (a *agent) Reset(g *game.Game) {
+	a.fallback.Reset(g)
+}
 
-		curBoard := g.Board()
-		height := uint64(len(curBoard))
-		width := uint64(len(curBoard[0]))
-		var maxCombines uint64
+func (a *agent) ChooseMove(g *game.Game) game.Direction {
+	var dirs []game.Direction
 
-		//fmt.Println(g)
+	curBoard := g.Board()
+	height := uint64(len(curBoard))
+	width := uint64(len(curBoard[0]))
+	var maxCombines uint64
 
-		// up
-		var totalCombines uint64
-		for col := uint64(0); col < width; col++ {
-			for row := height - 1; row > 0 && row != math.MaxUint64; row-- {
-				rownext := row - 1
+	//fmt.Println(g)
 
-				// find the next non-blank square
-				for rownext != math.MaxUint64 && curBoard[rownext][col] == 0 {
-					rownext--
-				}
+	// up
+	var totalCombines uint64
+	for col := uint64(0); col < width; col++ {
+		for row := height - 1; row > 0 && row != math.MaxUint64; row-- {
+			rownext := row - 1
 
-				if rownext != math.MaxUint64 && curBoard[row][col] == curBoard[rownext][col] && curBoard[row][col] != 0 {
-					totalCombines++
-					row--
-				}
+			// find the next non-blank square
+			for rownext != math.MaxUint64 && curBoard[rownext][col] == 0 {
+				rownext--
+			}
+
+			if rownext != math.MaxUint64 && curBoard[row][col] == curBoard[rownext][col] && curBoard[row][col] != 0 {
+				totalCombines++
+				row--
 			}
 		}
+	}
 
-		//fmt.Println("up", totalCombines)
+	//fmt.Println("up", totalCombines)
 
-		maxCombines = totalCombines
+	maxCombines = totalCombines
 
-		if totalCombines > 0 {
-			dirs = append(dirs, game.DirUp)
-		}
+	if totalCombines > 0 {
+		dirs = append(dirs, game.DirUp)
+	}
 
-		// down
-		totalCombines = 0
-		for col := uint64(0); col < width; col++ {
-			for row := uint64(0); row < height-2; row++ {
-				rownext := row + 1
-
-				// find the next non-blank square
-				for rownext < height && curBoard[rownext][col] == 0 {
-					rownext++
-				}
-
-				if rownext < height && curBoard[row][col] == curBoard[rownext][col] && curBoard[row][col] != 0 {
-					totalCombines++
-					row++
-				}
+	// down
+	totalCombines = 0
+	for col := uint64(0); col < width; col++ {
+		for row := uint64(0); row < height-2; row++ {
+			rownext := row + 1
+
+			// find the next non-blank square
+			for rownext < height && curBoard[rownext][col] == 0 {
+				rownext++
+			}
+
+			if rownext < height && curBoard[row][col] == curBoard[rownext][col] && curBoard[row][col] != 0 {
+				totalCombines++
+				row++
 			}
 		}
+	}
 
-		//fmt.Println("down", totalCombines)
+	//fmt.Println("down", totalCombines)
 
-		if totalCombines > 0 && totalCombines == maxCombines {
-			dirs = append(dirs, game.DirDown)
-		} else if totalCombines > maxCombines {
-			maxCombines = totalCombines
-			dirs = []game.Direction{game.DirDown}
-		}
+	if totalCombines > 0 && totalCombines == maxCombines {
+		dirs = append(dirs, game.DirDown)
+	} else if totalCombines > maxCombines {
+		maxCombines = totalCombines
+		dirs = []game.Direction{game.DirDown}
+	}
+
+	// left
+	totalCombines = 0
+	for row := uint64(0); row < height; row++ {
+		for col := width - 1; col > 0 && col != math.MaxUint64; col-- {
+			colnext := col - 1
 
-		// left
-		totalCombines = 0
-		for row := uint64(0); row < height; row++ {
-			for col := width - 1; col > 0 && col != math.MaxUint64; col-- {
-				colnext := col - 1
-
-				// find the next non-blank square
-				for colnext != math.MaxUint64 && curBoard[row][colnext] == 0 {
-					colnext--
-				}
-
-				if colnext != math.MaxUint64 && curBoard[row][col] == curBoard[row][colnext] && curBoard[row][col] != 0 {
-					totalCombines++
-					col--
-				}
+			// find the next non-blank square
+			for colnext != math.MaxUint64 && curBoard[row][colnext] == 0 {
+				colnext--
+			}
+
+			if colnext != math.MaxUint64 && curBoard[row][col] == curBoard[row][colnext] && curBoard[row][col] != 0 {
+				totalCombines++
+				col--
 			}
 		}
+	}
 
-		//fmt.Println("left", totalCombines)
+	//fmt.Println("left", totalCombines)
 
-		if totalCombines > 0 && totalCombines == maxCombines {
-			dirs = append(dirs, game.DirLeft)
-		} else if totalCombines > maxCombines {
-			maxCombines = totalCombines
-			dirs = []game.Direction{game.DirLeft}
-		}
+	if totalCombines > 0 && totalCombines == maxCombines {
+		dirs = append(dirs, game.DirLeft)
+	} else if totalCombines > maxCombines {
+		maxCombines = totalCombines
+		dirs = []game.Direction{game.DirLeft}
+	}
 
-		// right
-		totalCombines = 0
-		for row := uint64(0); row < height; row++ {
-			for col := uint64(0); col < width-2; col++ {
-				colnext := col + 1
-
-				// find the next non-blank square
-				for colnext < width && curBoard[row][colnext] == 0 {
-					colnext++
-				}
-
-				if colnext < width && curBoard[row][col] == curBoard[row][col+1] && curBoard[row][col] != 0 {
-					totalCombines++
-					col++
-				}
-			}
-		}
+	// right
+	totalCombines = 0
+	for row := uint64(0); row < height; row++ {
+		for col := uint64(0); col < width-2; col++ {
+			colnext := col + 1
 
-		//fmt.Println("right", totalCombines)
+			// find the next non-blank square
+			for colnext < width && curBoard[row][colnext] == 0 {
+				colnext++
+			}
 
-		if totalCombines > 0 && totalCombines == maxCombines {
-			dirs = append(dirs, game.DirRight)
-		} else if totalCombines > maxCombines {
-			maxCombines = totalCombines
-			dirs = []game.Direction{game.DirRight}
+			if colnext < width && curBoard[row][col] == curBoard[row][col+1] && curBoard[row][col] != 0 {
+				totalCombines++
+				col++
+			}
 		}
+	}
 
-		//fmt.Println(dirs)
+	//fmt.Println("right", totalCombines)
 
-		if len(dirs) == 0 {
-			dirs = []game.Direction{game.DirUp, game.DirDown, game.DirLeft, game.DirRight}
-		}
+	if totalCombines > 0 && totalCombines == maxCombines {
+		dirs = append(dirs, game.DirRight)
+	} else if totalCombines > maxCombines {
+		maxCombines = totalCombines
+		dirs = []game.Direction{game.DirRight}
+	}
 
-		dir := dirs[rnd.Intn(len(dirs))]
+	//fmt.Println(dirs)
 
-		//fmt.Println(dir)
-		g.Move(dir)
+	// anything that combines moves, so only the fallback needs to know
+	// which moves did nothing
+	if len(dirs) == 0 {
+		return a.fallback.ChooseMove(g)
 	}
+
+	return dirs[a.rnd.Intn(len(dirs))]
+}
+
+func (a *agent) Observe(dir game.Direction, out game.Outcome) {
+	a.fallback.Observe(dir, out)
 }
@@ -0,0 +1,110 @@
+package mcts
+
+import (
+	crand "crypto/rand"
+	"encoding/binary"
+	"math/rand"
+	"time"
+
+	"github.com/dystopium/2048/game"
+	"github.com/dystopium/2048/players"
+	"github.com/dystopium/2048/players/random"
+)
+
+func newRand() *rand.Rand {
+	b := make([]byte, 8)
+	crand.Read(b)
+	seed := binary.LittleEndian.Uint64(b)
+	return rand.New(rand.NewSource(int64(seed)))
+}
+
+// New creates an agent that picks each move by playing random games to the
+// end from every direction and choosing the one with the best average score
+// If budget is more than 0, it plays as many games as it can in that much
+// time per move, otherwise it plays playouts games per direction
+func New(playouts uint64, budget time.Duration) players.Agent {
+	if playouts == 0 {
+		playouts = 1
+	}
+
+	return &agent{
+		rnd:      newRand(),
+		rollout:  random.New(),
+		playouts: playouts,
+		budget:   budget,
+	}
+}
+
+type agent struct {
+	rnd *rand.Rand
+
+	// rollout plays the random games
+	rollout players.Agent
+
+	playouts uint64
+	budget   time.Duration
+}
+
+func (a *agent) Reset(g *game.Game) {}
+
+// ChooseMove runs the playouts for a single move
+func (a *agent) ChooseMove(g *game.Game) game.Direction {
+	var dirs []game.Direction
+
+	for _, dir := range game.Directions {
+		if _, _, moved := g.Simulate(dir); moved {
+			dirs = append(dirs, dir)
+		}
+	}
+
+	// nothing can move, any move will end the game
+	if len(dirs) == 0 {
+		return game.DirUp
+	}
+
+	if len(dirs) == 1 {
+		return dirs[0]
+	}
+
+	totals := make([]uint64, len(dirs))
+	counts := make([]uint64, len(dirs))
+	start := time.Now()
+
+	for round := uint64(0); ; round++ {
+		if a.budget > 0 {
+			if round > 0 && time.Since(start) >= a.budget {
+				break
+			}
+		} else if round >= a.playouts {
+			break
+		}
+
+		for i, dir := range dirs {
+			totals[i] += a.playout(g, dir, a.rnd.Int63())
+			counts[i]++
+		}
+	}
+
+	best := 0
+	for i := range dirs {
+		// compare averages without dividing
+		if totals[i]*counts[best] > totals[best]*counts[i] {
+			best = i
+		}
+	}
+
+	return dirs[best]
+}
+
+// playout plays a random game to the end after moving in dir
+// Returns the final score
+func (a *agent) playout(g *game.Game, dir game.Direction, seed int64) uint64 {
+	sim := g.Clone()
+	sim.Reseed(seed)
+	sim.Move(dir)
+
+	// random moves can't go wrong
+	players.Run(sim, a.rollout)
+
+	return sim.Score()
+}
@@ -0,0 +1,136 @@
+package ntuple
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+
+	"github.com/dystopium/2048/game"
+	"github.com/dystopium/2048/internal/binio"
+)
+
+// magic starts every weights file
+const magic = "2048ntn"
+
+// Version is the version of the weights format written by Write
+const Version = 1
+
+// ErrNotWeights is returned when reading something that isn't a weights file
+var ErrNotWeights = errors.New("ntuple: not a weights file")
+
+// Write writes n's tuples and weights to w
+// After the magic string every number is a varint, except the weights
+// which are little endian float32s.
+func Write(w io.Writer, n *Network) error {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	bw := binio.NewWriter(w)
+
+	bw.String(magic)
+	bw.Uvarint(Version)
+	bw.Uvarint(n.width)
+	bw.Uvarint(n.height)
+
+	bw.Uvarint(uint64(len(n.tuples)))
+	for _, t := range n.tuples {
+		bw.Uvarint(uint64(len(t)))
+		for _, sq := range t {
+			bw.Uvarint(sq.Row)
+			bw.Uvarint(sq.Col)
+		}
+	}
+
+	for _, ws := range n.weights {
+		for _, x := range ws {
+			bw.Uint32(math.Float32bits(x))
+		}
+	}
+
+	return bw.Flush()
+}
+
+// Read reads a network written by Write
+func Read(r io.Reader) (*Network, error) {
+	br := binio.NewReader(r)
+
+	if !br.Magic(magic) {
+		return nil, ErrNotWeights
+	}
+
+	version := br.Uvarint()
+	if br.Err() == nil && version != Version {
+		return nil, fmt.Errorf("ntuple: unsupported version %v", version)
+	}
+
+	width := br.Uvarint()
+	height := br.Uvarint()
+
+	var tuples []Tuple
+
+	count := br.Uvarint()
+	for i := uint64(0); i < count && br.Err() == nil; i++ {
+		size := br.Uvarint()
+
+		// anything bigger is rejected by NewNetwork anyway
+		if size > 32/bits {
+			return nil, ErrBadTuple
+		}
+
+		t := make(Tuple, size)
+		for j := range t {
+			t[j] = game.Square{Row: br.Uvarint(), Col: br.Uvarint()}
+		}
+
+		tuples = append(tuples, t)
+	}
+
+	if err := br.Err(); err != nil {
+		return nil, err
+	}
+
+	n, err := NewNetwork(width, height, tuples)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, ws := range n.weights {
+		for i := range ws {
+			ws[i] = math.Float32frombits(br.Uint32())
+		}
+
+		if err := br.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	return n, nil
+}
+
+// Save writes n to the file name
+func Save(name string, n *Network) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+
+	if err := Write(f, n); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// Load reads a network written by Save
+func Load(name string) (*Network, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return Read(f)
+}
@@ -0,0 +1,144 @@
+package ntuple
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/dystopium/2048/game"
+	"github.com/dystopium/2048/internal/binio"
+	"github.com/dystopium/2048/players"
+)
+
+// trained is a network that has learnt from a few games, so its weights
+// aren't all zero
+func trained(t *testing.T) *Network {
+	t.Helper()
+
+	n, err := NewNetwork(4, 4, FourTuples)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l := NewLearner(n, DefaultAlpha, 0.5)
+	for seed := int64(0); seed < 20; seed++ {
+		if err := players.Run(game.NewSeededGame(4, 4, 11, 1, seed), l); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return n
+}
+
+func TestRoundTrip(t *testing.T) {
+	n := trained(t)
+
+	buf := &bytes.Buffer{}
+	if err := Write(buf, n); err != nil {
+		t.Fatal(err)
+	}
+
+	read, err := Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if read.Width() != n.Width() || read.Height() != n.Height() || !reflect.DeepEqual(read.Tuples(), n.Tuples()) {
+		t.Fatalf("read a %vx%v network with %v, want %vx%v with %v", read.Width(), read.Height(), read.Tuples(), n.Width(), n.Height(), n.Tuples())
+	}
+
+	if !reflect.DeepEqual(read.weights, n.weights) {
+		t.Fatal("read weights differ from the written ones")
+	}
+
+	// both have to play the same games from here on
+	for seed := int64(100); seed < 105; seed++ {
+		a := game.NewSeededGame(4, 4, 11, 1, seed)
+		b := game.NewSeededGame(4, 4, 11, 1, seed)
+
+		if err := players.Run(a, New(n)); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := players.Run(b, New(read)); err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(a.Board(), b.Board()) || a.Score() != b.Score() {
+			t.Fatalf("seed %v: read network finished at\n%v\nscoring %v, want\n%v\nscoring %v", seed, b, b.Score(), a, a.Score())
+		}
+	}
+}
+
+// header writes the start of a weights file, up to the weights
+func header(t *testing.T, version, width, height uint64, tuples ...Tuple) []byte {
+	t.Helper()
+
+	buf := &bytes.Buffer{}
+	w := binio.NewWriter(buf)
+
+	w.String(magic)
+	w.Uvarint(version)
+	w.Uvarint(width)
+	w.Uvarint(height)
+
+	w.Uvarint(uint64(len(tuples)))
+	for _, tuple := range tuples {
+		w.Uvarint(uint64(len(tuple)))
+		for _, sq := range tuple {
+			w.Uvarint(sq.Row)
+			w.Uvarint(sq.Col)
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestReadCorrupt(t *testing.T) {
+	pair := Tuple{{Row: 0, Col: 0}, {Row: 0, Col: 1}}
+
+	// a pair has 16*16 weights of 4 bytes each, one short of them all
+	weights := bytes.Repeat([]byte{0}, 16*16*4)
+	short := append(header(t, Version, 4, 4, pair), weights[1:]...)
+	if _, err := Read(bytes.NewReader(short)); err != io.ErrUnexpectedEOF {
+		t.Errorf("missing weight: got error %v, want %v", err, io.ErrUnexpectedEOF)
+	}
+
+	if _, err := Read(bytes.NewReader(append(header(t, Version, 4, 4, pair), weights...))); err != nil {
+		t.Errorf("pair: %v", err)
+	}
+
+	save, err := game.NewSeededGame(4, 4, 11, 1, 1).MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Read(bytes.NewReader(save)); !errors.Is(err, ErrNotWeights) {
+		t.Errorf("saved game: got error %v, want %v", err, ErrNotWeights)
+	}
+
+	if _, err := Read(bytes.NewReader(header(t, Version+1, 4, 4, pair))); err == nil {
+		t.Errorf("version %v: got no error", Version+1)
+	}
+
+	big := make(Tuple, 9)
+	for i := range big {
+		big[i] = game.Square{Row: uint64(i) / 4, Col: uint64(i) % 4}
+	}
+
+	for name, data := range map[string][]byte{
+		"no tuples":     header(t, Version, 4, 4),
+		"9 square":      header(t, Version, 4, 4, big),
+		"off the board": header(t, Version, 4, 4, Tuple{{Row: 0, Col: 0}, {Row: 4, Col: 0}}),
+	} {
+		if _, err := Read(bytes.NewReader(data)); !errors.Is(err, ErrBadTuple) {
+			t.Errorf("%v: got error %v, want %v", name, err, ErrBadTuple)
+		}
+	}
+}
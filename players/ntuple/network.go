@@ -0,0 +1,262 @@
+package ntuple
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/dystopium/2048/game"
+)
+
+// bits is how many bits of a tuple's index each square takes
+// Tiles past 2^15 all share the largest value.
+const bits = 4
+
+// maxPower is the largest tile power a square can tell apart
+const maxPower = 1<<bits - 1
+
+// Tuple is a group of squares looked at together
+// Every combination of tiles on them has its own weight.
+type Tuple []game.Square
+
+// FourTuples are small tuples for 4x4 boards, the outer and inner rows and
+// 2x2 squares in the corner, along the edge and in the middle
+// They learn quickly and need less than 2MB of weights.
+var FourTuples = []Tuple{
+	{{Row: 0, Col: 0}, {Row: 0, Col: 1}, {Row: 0, Col: 2}, {Row: 0, Col: 3}},
+	{{Row: 1, Col: 0}, {Row: 1, Col: 1}, {Row: 1, Col: 2}, {Row: 1, Col: 3}},
+	{{Row: 0, Col: 0}, {Row: 0, Col: 1}, {Row: 1, Col: 0}, {Row: 1, Col: 1}},
+	{{Row: 0, Col: 1}, {Row: 0, Col: 2}, {Row: 1, Col: 1}, {Row: 1, Col: 2}},
+	{{Row: 1, Col: 1}, {Row: 1, Col: 2}, {Row: 2, Col: 1}, {Row: 2, Col: 2}},
+}
+
+// SixTuples are the larger tuples for 4x4 boards from the 2048 literature
+// They play much better once trained but need 256MB of weights and many
+// more games to get there.
+var SixTuples = []Tuple{
+	{{Row: 0, Col: 0}, {Row: 0, Col: 1}, {Row: 0, Col: 2}, {Row: 0, Col: 3}, {Row: 1, Col: 0}, {Row: 1, Col: 1}},
+	{{Row: 1, Col: 0}, {Row: 1, Col: 1}, {Row: 1, Col: 2}, {Row: 1, Col: 3}, {Row: 2, Col: 0}, {Row: 2, Col: 1}},
+	{{Row: 0, Col: 0}, {Row: 0, Col: 1}, {Row: 0, Col: 2}, {Row: 1, Col: 0}, {Row: 1, Col: 1}, {Row: 1, Col: 2}},
+	{{Row: 1, Col: 0}, {Row: 1, Col: 1}, {Row: 1, Col: 2}, {Row: 2, Col: 0}, {Row: 2, Col: 1}, {Row: 2, Col: 2}},
+}
+
+// ErrBadTuple is returned for tuples that are empty, too big or don't fit the board
+var ErrBadTuple = errors.New("ntuple: bad tuple")
+
+// Network scores boards by adding up the weight of every tuple in every
+// symmetry of the board
+// It's safe to use from many goroutines, learning locks out everything else.
+type Network struct {
+	mu sync.RWMutex
+
+	width  uint64
+	height uint64
+	tuples []Tuple
+
+	// placements are the tuples mapped by every symmetry of the board,
+	// placement i uses the weights of tuple table[i]
+	placements []Tuple
+	table      []int
+
+	weights [][]float32
+}
+
+// NewNetwork creates a network of tuples for boards of the size given,
+// with every weight starting at 0
+func NewNetwork(width, height uint64, tuples []Tuple) (*Network, error) {
+	if len(tuples) == 0 {
+		return nil, ErrBadTuple
+	}
+
+	n := &Network{
+		width:  width,
+		height: height,
+		tuples: tuples,
+	}
+
+	for i, t := range tuples {
+		if len(t) == 0 || len(t)*bits > 32 {
+			return nil, ErrBadTuple
+		}
+
+		for _, sq := range t {
+			if sq.Row >= height || sq.Col >= width {
+				return nil, ErrBadTuple
+			}
+		}
+
+		n.weights = append(n.weights, make([]float32, 1<<(len(t)*bits)))
+
+		for _, p := range symmetries(t, width, height) {
+			n.placements = append(n.placements, p)
+			n.table = append(n.table, i)
+		}
+	}
+
+	return n, nil
+}
+
+// symmetries maps t by every rotation and reflection that maps the board
+// onto itself, 8 for square boards and 4 otherwise
+func symmetries(t Tuple, width, height uint64) []Tuple {
+	var ret []Tuple
+
+	for _, transpose := range []bool{false, true} {
+		// rotating a rectangle doesn't fit it back on itself
+		if transpose && width != height {
+			continue
+		}
+
+		for _, flipRow := range []bool{false, true} {
+			for _, flipCol := range []bool{false, true} {
+				p := make(Tuple, len(t))
+
+				for i, sq := range t {
+					row, col := sq.Row, sq.Col
+
+					if transpose {
+						row, col = col, row
+					}
+
+					if flipRow {
+						row = height - 1 - row
+					}
+
+					if flipCol {
+						col = width - 1 - col
+					}
+
+					p[i] = game.Square{Row: row, Col: col}
+				}
+
+				ret = append(ret, p)
+			}
+		}
+	}
+
+	return ret
+}
+
+// Width is the width of the boards the network scores
+func (n *Network) Width() uint64 {
+	return n.width
+}
+
+// Height is the height of the boards the network scores
+func (n *Network) Height() uint64 {
+	return n.height
+}
+
+// Tuples are the tuples the network was created with
+func (n *Network) Tuples() []Tuple {
+	return n.tuples
+}
+
+// fits reports whether board is the size the network scores
+func (n *Network) fits(board [][]uint64) bool {
+	return uint64(len(board)) == n.height && uint64(len(board[0])) == n.width
+}
+
+// Value estimates the score still to come after reaching board
+func (n *Network) Value(board [][]uint64) float64 {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	return n.value(board)
+}
+
+func (n *Network) value(board [][]uint64) float64 {
+	var total float64
+
+	for i, p := range n.placements {
+		total += float64(n.weights[n.table[i]][index(board, p)])
+	}
+
+	return total
+}
+
+// index finds the weight for the tiles on board under p
+func index(board [][]uint64, p Tuple) uint32 {
+	var idx uint32
+
+	for _, sq := range p {
+		val := board[sq.Row][sq.Col]
+		if val > maxPower {
+			val = maxPower
+		}
+
+		idx = idx<<bits | uint32(val)
+	}
+
+	return idx
+}
+
+// features finds the weight for every placement on board, appending them to buf
+func (n *Network) features(board [][]uint64, buf []uint32) []uint32 {
+	for _, p := range n.placements {
+		buf = append(buf, index(board, p))
+	}
+
+	return buf
+}
+
+// featureValue adds up the weights found by features
+func (n *Network) featureValue(feats []uint32) float64 {
+	var total float64
+
+	for i, f := range feats {
+		total += float64(n.weights[n.table[i]][f])
+	}
+
+	return total
+}
+
+// update moves the value of the board with feats by delta, shared evenly
+// between the placements
+func (n *Network) update(feats []uint32, delta float64) {
+	share := float32(delta / float64(len(feats)))
+
+	for i, f := range feats {
+		n.weights[n.table[i]][f] += share
+	}
+}
+
+// learn updates the network from a whole game by TD(lambda), going
+// backwards from the end
+// afters holds the features of the board after each move, before the new
+// tiles, and rewards the score from each move. The game ended after the last.
+func (n *Network) learn(afters []uint32, rewards []uint64, alpha, lambda float64) {
+	steps := len(rewards)
+	if steps == 0 {
+		return
+	}
+
+	size := len(afters) / steps
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	// ret is the lambda return of the board after each move, nothing is
+	// gained after the last one
+	var ret float64
+
+	for t := steps - 1; t >= 0; t-- {
+		feats := afters[t*size : (t+1)*size]
+
+		n.update(feats, alpha*(ret-n.featureValue(feats)))
+
+		if t > 0 {
+			ret = float64(rewards[t]) + (1-lambda)*n.featureValue(feats) + lambda*ret
+		}
+	}
+}
+
+// String describes the shape of the network
+func (n *Network) String() string {
+	var size int
+	for _, w := range n.weights {
+		size += len(w)
+	}
+
+	return fmt.Sprintf("%vx%v board, %v tuples, %v placements, %v weights", n.width, n.height, len(n.tuples), len(n.placements), size)
+}
@@ -0,0 +1,93 @@
+package ntuple
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/dystopium/2048/game"
+	"github.com/dystopium/2048/players"
+)
+
+// DefaultAlpha is a learning rate that works well for both sets of tuples
+const DefaultAlpha = 0.1
+
+// New creates an agent that makes the move leading to the board n values
+// most, counting the score the move gets
+func New(n *Network) players.Agent {
+	return &agent{n: n}
+}
+
+// NewLearner creates an agent that plays like New, and at the end of each
+// game updates n by TD(lambda) on the boards after each of its moves
+// alpha is the learning rate, and lambda is 0 for TD(0).
+func NewLearner(n *Network, alpha, lambda float64) players.Agent {
+	return &agent{n: n, learn: true, alpha: alpha, lambda: lambda}
+}
+
+type agent struct {
+	n *Network
+
+	learn  bool
+	alpha  float64
+	lambda float64
+
+	// afters are the boards each direction moved to when last choosing
+	afters [4][][]uint64
+
+	// the features and score of every move so far this game
+	feats   []uint32
+	rewards []uint64
+}
+
+func (a *agent) Reset(g *game.Game) {
+	if g.Width() != a.n.Width() || g.Height() != a.n.Height() {
+		panic(fmt.Sprintf("ntuple: network is for %vx%v boards, not %vx%v", a.n.Width(), a.n.Height(), g.Width(), g.Height()))
+	}
+
+	a.feats = a.feats[:0]
+	a.rewards = a.rewards[:0]
+}
+
+func (a *agent) ChooseMove(g *game.Game) game.Direction {
+	board := g.Board()
+
+	best := game.DirUp
+	bestVal := math.Inf(-1)
+
+	for i, dir := range game.Directions {
+		next, score, moved := game.Slide(board, dir)
+
+		a.afters[i] = nil
+		if !moved {
+			continue
+		}
+
+		a.afters[i] = next
+
+		if val := float64(score) + a.n.Value(next); val > bestVal {
+			best = dir
+			bestVal = val
+		}
+	}
+
+	return best
+}
+
+func (a *agent) Observe(dir game.Direction, out game.Outcome) {
+	if !a.learn || !out.Moved {
+		return
+	}
+
+	for i, d := range game.Directions {
+		if d == dir && a.afters[i] != nil {
+			a.feats = a.n.features(a.afters[i], a.feats)
+			a.rewards = append(a.rewards, out.Score)
+		}
+	}
+
+	if out.State != game.StatePlaying {
+		a.n.learn(a.feats, a.rewards, a.alpha, a.lambda)
+		a.feats = a.feats[:0]
+		a.rewards = a.rewards[:0]
+	}
+}
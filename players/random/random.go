@@ -6,6 +6,7 @@ import (
 	"math/rand"
 
 	"github.com/dystopium/2048/game"
+	"github.com/dystopium/2048/players"
 )
 
 func newRand() *rand.Rand {
@@ -15,32 +16,51 @@ func newRand() *rand.Rand {
 	return rand.New(rand.NewSource(int64(seed)))
 }
 
-// Play allows the human to play
+// Play makes random moves until the game ends
 func Play(g *game.Game) {
-	rnd := newRand()
+	players.Func(New)(g)
+}
 
-	var currnd uint64
+// New creates an agent that makes random moves
+func New() players.Agent {
+	return &agent{rnd: newRand()}
+}
 
-	for g.State() == game.StatePlaying {
-		if currnd == 0 {
-			currnd = rnd.Uint64()
-		}
+type agent struct {
+	rnd *rand.Rand
 
-		dir := currnd & 0x3
-		currnd >>= 2
+	// the first left directions are the ones not yet known to do nothing
+	// on this board
+	dirs []game.Direction
+	left int
+}
 
-		switch dir {
-		case 0:
-			g.Move(game.DirUp)
+func (a *agent) Reset(g *game.Game) {
+	a.dirs = append(a.dirs[:0], game.Directions...)
+	a.left = len(a.dirs)
+}
 
-		case 1:
-			g.Move(game.DirDown)
+func (a *agent) ChooseMove(g *game.Game) game.Direction {
+	// every direction has been tried, so any move will end the game
+	if a.left == 0 {
+		return game.DirUp
+	}
+
+	return a.dirs[a.rnd.Intn(a.left)]
+}
 
-		case 2:
-			g.Move(game.DirLeft)
+func (a *agent) Observe(dir game.Direction, out game.Outcome) {
+	if out.Moved {
+		a.left = len(a.dirs)
+		return
+	}
 
-		case 3:
-			g.Move(game.DirRight)
+	// don't try it again until something changes
+	for i := 0; i < a.left; i++ {
+		if a.dirs[i] == dir {
+			a.left--
+			a.dirs[i], a.dirs[a.left] = a.dirs[a.left], a.dirs[i]
+			return
 		}
 	}
 }
@@ -3,4 +3,32 @@ package players
 import "github.com/dystopium/2048/game"
 
 // Player represents something that can attempt to win the game
+// It owns the whole game loop, see Agent for one that's driven a move at a time.
 type Player func(*game.Game)
+
+// Agent is something that can attempt to win the game one move at a time,
+// leaving the game loop to whoever runs it
+// An agent plays one game at a time but may be reused for many, so it can
+// learn between them.
+type Agent interface {
+	// Reset gets ready to play a new game
+	Reset(g *game.Game)
+
+	// ChooseMove picks the next move in g
+	// It must not move g itself, but is free to look at, clone or simulate
+	// it. It may also change g the way a player at the console can, with
+	// Undo, Redo or loading a saved game, which g's observers are told
+	// about. If that leaves g won or lost, the direction is ignored.
+	ChooseMove(g *game.Game) game.Direction
+}
+
+// Observer is an Agent that wants to know what each of its moves did
+type Observer interface {
+	Agent
+
+	Observe(dir game.Direction, out game.Outcome)
+}
+
+// AgentGen creates a new agent each time it is called
+// Runners playing games at the same time create one agent for each.
+type AgentGen func() Agent
@@ -0,0 +1,100 @@
+package replay
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/dystopium/2048/game"
+	"github.com/dystopium/2048/internal/binio"
+)
+
+// magic starts every binary record
+const magic = "2048rec"
+
+// ErrNotBinary is returned when reading something that isn't a binary record
+var ErrNotBinary = errors.New("replay: not a binary record")
+
+// WriteBinary writes rec to w in a compact binary format
+// After the magic string every number is a varint, and each direction is a byte.
+func WriteBinary(w io.Writer, rec *Record) error {
+	bw := binio.NewWriter(w)
+
+	putSpawns := func(spawns []game.Spawn) {
+		bw.Uvarint(uint64(len(spawns)))
+		for _, sp := range spawns {
+			bw.Uvarint(sp.Row)
+			bw.Uvarint(sp.Col)
+			bw.Uvarint(sp.Value)
+		}
+	}
+
+	bw.String(magic)
+	bw.Uvarint(rec.Version)
+	bw.Uvarint(rec.Width)
+	bw.Uvarint(rec.Height)
+	bw.Uvarint(rec.Limit)
+	bw.Uvarint(rec.Adds)
+	bw.Varint(rec.Seed)
+	bw.Uvarint(rec.Score)
+	bw.Uvarint(rec.RNG)
+
+	putSpawns(rec.Initial)
+
+	bw.Uvarint(uint64(len(rec.Steps)))
+	for _, step := range rec.Steps {
+		bw.Byte(byte(step.Dir))
+		putSpawns(step.Spawns)
+	}
+
+	return bw.Flush()
+}
+
+// ReadBinary reads a record written by WriteBinary
+func ReadBinary(r io.Reader) (*Record, error) {
+	br := binio.NewReader(r)
+
+	if !br.Magic(magic) {
+		return nil, ErrNotBinary
+	}
+
+	spawns := func() []game.Spawn {
+		count := br.Uvarint()
+
+		var ret []game.Spawn
+		for i := uint64(0); i < count && br.Err() == nil; i++ {
+			ret = append(ret, game.Spawn{Row: br.Uvarint(), Col: br.Uvarint(), Value: br.Uvarint()})
+		}
+
+		return ret
+	}
+
+	rec := &Record{}
+	rec.Version = br.Uvarint()
+
+	if br.Err() == nil && rec.Version != Version {
+		return nil, fmt.Errorf("replay: unsupported version %v", rec.Version)
+	}
+
+	rec.Width = br.Uvarint()
+	rec.Height = br.Uvarint()
+	rec.Limit = br.Uvarint()
+	rec.Adds = br.Uvarint()
+	rec.Seed = br.Varint()
+	rec.Score = br.Uvarint()
+	rec.RNG = br.Uvarint()
+
+	rec.Initial = spawns()
+
+	count := br.Uvarint()
+	for i := uint64(0); i < count && br.Err() == nil; i++ {
+		dir := game.Direction(br.Byte())
+		rec.Steps = append(rec.Steps, Step{Dir: dir, Spawns: spawns()})
+	}
+
+	if err := br.Err(); err != nil {
+		return nil, err
+	}
+
+	return rec, nil
+}
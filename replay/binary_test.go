@@ -0,0 +1,122 @@
+package replay
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/dystopium/2048/game"
+)
+
+// recorded plays a game from a position with a score, so every field of
+// the record is set, and returns the record and the game
+func recorded(t *testing.T) (*Record, *game.Game) {
+	t.Helper()
+
+	board := [][]uint64{{1, 1, 0, 2}, {0, 3, 0, 0}, {0, 0, 0, 0}, {4, 0, 0, 1}}
+	g, err := game.NewGameFromBoard(board, 11, 2, 1000, -5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := NewRecorder(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	play(g, game.DirLeft, game.DirUp, game.DirRight, game.DirDown, game.DirLeft)
+
+	return r.Record(), g
+}
+
+// checkRoundTrip checks that read gives back rec, and that its replay
+// carries on exactly like g
+func checkRoundTrip(t *testing.T, rec, read *Record, g *game.Game) {
+	t.Helper()
+
+	if !reflect.DeepEqual(read, rec) {
+		t.Fatalf("read %+v, want %+v", read, rec)
+	}
+
+	replayed, err := Replay(read)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	more := []game.Direction{game.DirUp, game.DirRight, game.DirDown, game.DirLeft, game.DirUp}
+	play(g, more...)
+	play(replayed, more...)
+
+	if !reflect.DeepEqual(replayed.Board(), g.Board()) || replayed.Score() != g.Score() {
+		t.Fatalf("replay carried on to\n%v\nscoring %v, want\n%v\nscoring %v", replayed, replayed.Score(), g, g.Score())
+	}
+}
+
+func TestBinaryRoundTrip(t *testing.T) {
+	rec, g := recorded(t)
+
+	buf := &bytes.Buffer{}
+	if err := WriteBinary(buf, rec); err != nil {
+		t.Fatal(err)
+	}
+
+	read, err := ReadBinary(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkRoundTrip(t, rec, read, g)
+}
+
+// TestBinaryEmpty checks a record with no moves, which has no steps to
+// write rather than an empty list
+func TestBinaryEmpty(t *testing.T) {
+	g := game.NewSeededGame(4, 4, 11, 2, 9)
+
+	r, err := NewRecorder(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := r.Record()
+
+	buf := &bytes.Buffer{}
+	if err := WriteBinary(buf, rec); err != nil {
+		t.Fatal(err)
+	}
+
+	read, err := ReadBinary(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkRoundTrip(t, rec, read, g)
+}
+
+func TestBinaryCorrupt(t *testing.T) {
+	rec, _ := recorded(t)
+
+	// a record in the other format
+	buf := &bytes.Buffer{}
+	if err := WriteJSON(buf, rec); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ReadBinary(buf); !errors.Is(err, ErrNotBinary) {
+		t.Errorf("JSON record: got error %v, want %v", err, ErrNotBinary)
+	}
+
+	newer := *rec
+	newer.Version = Version + 1
+
+	buf.Reset()
+	if err := WriteBinary(buf, &newer); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ReadBinary(buf); err == nil {
+		t.Errorf("version %v: got no error", newer.Version)
+	}
+
+}
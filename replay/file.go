@@ -0,0 +1,48 @@
+package replay
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Save writes rec to the file name, as JSON if the name ends in .json
+// and in the binary format otherwise
+func Save(name string, rec *Record) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+
+	if isJSON(name) {
+		err = WriteJSON(f, rec)
+	} else {
+		err = WriteBinary(f, rec)
+	}
+
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// Load reads a record written by Save
+func Load(name string) (*Record, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if isJSON(name) {
+		return ReadJSON(f)
+	}
+
+	return ReadBinary(f)
+}
+
+func isJSON(name string) bool {
+	return strings.EqualFold(filepath.Ext(name), ".json")
+}
@@ -0,0 +1,112 @@
+package replay
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/dystopium/2048/game"
+)
+
+type jsonRecord struct {
+	Version uint64      `json:"version"`
+	Width   uint64      `json:"width"`
+	Height  uint64      `json:"height"`
+	Limit   uint64      `json:"limit"`
+	Adds    uint64      `json:"adds"`
+	Seed    int64       `json:"seed"`
+	Score   uint64      `json:"score"`
+	RNG     uint64      `json:"rng"`
+	Initial []jsonSpawn `json:"initial"`
+	Steps   []jsonStep  `json:"steps"`
+}
+
+type jsonStep struct {
+	Dir    string      `json:"dir"`
+	Spawns []jsonSpawn `json:"spawns"`
+}
+
+// jsonSpawn is a game.Spawn, value is the power of 2 of the tile
+type jsonSpawn struct {
+	Row   uint64 `json:"row"`
+	Col   uint64 `json:"col"`
+	Value uint64 `json:"value"`
+}
+
+// WriteJSON writes rec to w as JSON
+func WriteJSON(w io.Writer, rec *Record) error {
+	out := jsonRecord{
+		Version: rec.Version,
+		Width:   rec.Width,
+		Height:  rec.Height,
+		Limit:   rec.Limit,
+		Adds:    rec.Adds,
+		Seed:    rec.Seed,
+		Score:   rec.Score,
+		RNG:     rec.RNG,
+		Initial: toJSONSpawns(rec.Initial),
+		Steps:   make([]jsonStep, len(rec.Steps)),
+	}
+
+	for i, step := range rec.Steps {
+		out.Steps[i] = jsonStep{Dir: step.Dir.String(), Spawns: toJSONSpawns(step.Spawns)}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(out)
+}
+
+// ReadJSON reads a record written by WriteJSON
+func ReadJSON(r io.Reader) (*Record, error) {
+	var in jsonRecord
+	if err := json.NewDecoder(r).Decode(&in); err != nil {
+		return nil, err
+	}
+
+	rec := &Record{
+		Version: in.Version,
+		Width:   in.Width,
+		Height:  in.Height,
+		Limit:   in.Limit,
+		Adds:    in.Adds,
+		Seed:    in.Seed,
+		Score:   in.Score,
+		RNG:     in.RNG,
+		Initial: fromJSONSpawns(in.Initial),
+		Steps:   make([]Step, len(in.Steps)),
+	}
+
+	for i, step := range in.Steps {
+		dir, err := game.ParseDirection(step.Dir)
+		if err != nil {
+			return nil, err
+		}
+
+		rec.Steps[i] = Step{Dir: dir, Spawns: fromJSONSpawns(step.Spawns)}
+	}
+
+	return rec, nil
+}
+
+func toJSONSpawns(spawns []game.Spawn) []jsonSpawn {
+	ret := make([]jsonSpawn, len(spawns))
+	for i, sp := range spawns {
+		ret[i] = jsonSpawn(sp)
+	}
+
+	return ret
+}
+
+func fromJSONSpawns(spawns []jsonSpawn) []game.Spawn {
+	if len(spawns) == 0 {
+		return nil
+	}
+
+	ret := make([]game.Spawn, len(spawns))
+	for i, sp := range spawns {
+		ret[i] = game.Spawn(sp)
+	}
+
+	return ret
+}
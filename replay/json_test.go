@@ -0,0 +1,47 @@
+package replay
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestJSONRoundTrip(t *testing.T) {
+	rec, g := recorded(t)
+
+	buf := &bytes.Buffer{}
+	if err := WriteJSON(buf, rec); err != nil {
+		t.Fatal(err)
+	}
+
+	read, err := ReadJSON(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkRoundTrip(t, rec, read, g)
+}
+
+func TestJSONCorrupt(t *testing.T) {
+	rec, _ := recorded(t)
+
+	buf := &bytes.Buffer{}
+	if err := WriteJSON(buf, rec); err != nil {
+		t.Fatal(err)
+	}
+
+	data := buf.String()
+
+	if _, err := ReadJSON(strings.NewReader(data[:len(data)/2])); err == nil {
+		t.Error("truncated: got no error")
+	}
+
+	badDir := strings.Replace(data, `"dir": "Left"`, `"dir": "sideways"`, 1)
+	if badDir == data {
+		t.Fatal("no left move to corrupt")
+	}
+
+	if _, err := ReadJSON(strings.NewReader(badDir)); err == nil {
+		t.Error("unknown direction: got no error")
+	}
+}
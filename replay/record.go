@@ -0,0 +1,150 @@
+package replay
+
+import (
+	"errors"
+
+	"github.com/dystopium/2048/game"
+)
+
+// Version is the version of the record format written by this package
+const Version = 1
+
+// maxSquares is the most squares a record's board can have, far more than
+// any game worth recording, so a bad record can't make a huge board
+const maxSquares = 1 << 24
+
+// Record is everything needed to reproduce a game exactly
+type Record struct {
+	Version uint64
+
+	Width  uint64
+	Height uint64
+	Limit  uint64
+	Adds   uint64
+	Seed   int64
+
+	// Score is the score before the first move
+	Score uint64
+
+	// RNG is the state of the generator placing new tiles before the first
+	// move, see game.Game.RandState
+	RNG uint64
+
+	// Initial holds the tiles on the board before the first move
+	Initial []game.Spawn
+
+	// Steps holds every move that changed the board, in order
+	Steps []Step
+}
+
+// Step is a single move and the tiles placed after it
+type Step struct {
+	Dir    game.Direction
+	Spawns []game.Spawn
+}
+
+// ErrStarted is returned when recording a game that has already been played
+var ErrStarted = errors.New("replay: game has already been played")
+
+// Recorder builds a Record as a game is played
+// Undone moves are left out, and loading a saved game starts a new record
+// from the loaded position.
+type Recorder struct {
+	game.NopObserver
+
+	g   *game.Game
+	rec *Record
+
+	// redo holds the undone steps, latest last
+	redo []Step
+}
+
+// NewRecorder starts recording g, which must not have been moved yet
+// It can start from any position, not just a new seeded game.
+// The recorder is added to the game's observers.
+func NewRecorder(g *game.Game) (*Recorder, error) {
+	if g.TotalMoves() > 0 {
+		return nil, ErrStarted
+	}
+
+	r := &Recorder{g: g, rec: newRecord(g)}
+	g.AddObserver(r)
+
+	return r, nil
+}
+
+// newRecord starts a record from g as it is now
+func newRecord(g *game.Game) *Record {
+	return &Record{
+		Version: Version,
+		Width:   g.Width(),
+		Height:  g.Height(),
+		Limit:   g.Limit(),
+		Adds:    g.Adds(),
+		Seed:    g.Seed(),
+		Score:   g.Score(),
+		RNG:     g.RandState(),
+		Initial: tiles(g.Board()),
+	}
+}
+
+// Moved starts a new step
+// Steps that were undone can't be redone after another move.
+func (r *Recorder) Moved(dir game.Direction, _ uint64) {
+	r.rec.Steps = append(r.rec.Steps, Step{Dir: dir})
+	r.redo = nil
+}
+
+// Undone takes the last step out of the record
+func (r *Recorder) Undone() {
+	steps := r.rec.Steps
+	if len(steps) == 0 {
+		return
+	}
+
+	r.redo = append(r.redo, steps[len(steps)-1])
+	r.rec.Steps = steps[:len(steps)-1]
+}
+
+// Redone puts the last undone step back
+func (r *Recorder) Redone() {
+	if len(r.redo) == 0 {
+		return
+	}
+
+	r.rec.Steps = append(r.rec.Steps, r.redo[len(r.redo)-1])
+	r.redo = r.redo[:len(r.redo)-1]
+}
+
+// Loaded starts a new record from the loaded game, which the moves so far
+// don't lead to
+func (r *Recorder) Loaded() {
+	r.rec = newRecord(r.g)
+	r.redo = nil
+}
+
+// Spawned adds a tile to the current step
+func (r *Recorder) Spawned(sp game.Spawn) {
+	step := &r.rec.Steps[len(r.rec.Steps)-1]
+	step.Spawns = append(step.Spawns, sp)
+}
+
+// Record returns the record of the game so far
+func (r *Recorder) Record() *Record {
+	return r.rec
+}
+
+// tiles lists every tile on board, in row then column order
+func tiles(board [][]uint64) []game.Spawn {
+	var ret []game.Spawn
+
+	for row := range board {
+		for col, val := range board[row] {
+			if val != 0 {
+				ret = append(ret, game.Spawn{Row: uint64(row), Col: uint64(col), Value: val})
+			}
+		}
+	}
+
+	return ret
+}
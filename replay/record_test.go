@@ -0,0 +1,99 @@
+package replay
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/dystopium/2048/game"
+)
+
+// play makes every move in dirs that changes the board
+func play(g *game.Game, dirs ...game.Direction) {
+	for _, dir := range dirs {
+		g.Move(dir)
+	}
+}
+
+// checkReplay replays rec and checks it ends up where g is
+func checkReplay(t *testing.T, rec *Record, g *game.Game) {
+	t.Helper()
+
+	replayed, err := Replay(rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(replayed.Board(), g.Board()) || replayed.Score() != g.Score() || replayed.State() != g.State() {
+		t.Fatalf("replay ended at\n%v\nscoring %v, want\n%v\nscoring %v", replayed, replayed.Score(), g, g.Score())
+	}
+}
+
+func TestRecordUndoRedo(t *testing.T) {
+	g := game.NewSeededGame(4, 4, 11, 1, 7)
+	g.SetHistory(10)
+
+	r, err := NewRecorder(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	play(g, game.DirLeft, game.DirUp)
+	g.Undo()
+	play(g, game.DirRight, game.DirDown)
+	checkReplay(t, r.Record(), g)
+
+	g.Undo()
+	g.Undo()
+	g.Redo()
+	checkReplay(t, r.Record(), g)
+
+	g.Redo()
+	play(g, game.DirLeft, game.DirUp, game.DirRight)
+	checkReplay(t, r.Record(), g)
+
+	// moving forgets what could be redone
+	g.Undo()
+	play(g, game.DirDown)
+	g.Redo()
+	checkReplay(t, r.Record(), g)
+}
+
+func TestRecordLoad(t *testing.T) {
+	g := game.NewSeededGame(4, 4, 11, 1, 3)
+	play(g, game.DirLeft, game.DirUp, game.DirRight, game.DirDown)
+
+	saved, err := g.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g = game.NewSeededGame(4, 4, 11, 1, 4)
+
+	r, err := NewRecorder(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	play(g, game.DirUp, game.DirLeft)
+
+	if err := g.UnmarshalBinary(saved); err != nil {
+		t.Fatal(err)
+	}
+
+	play(g, game.DirLeft, game.DirDown, game.DirRight)
+	checkReplay(t, r.Record(), g)
+}
+
+func TestReplayBadBoard(t *testing.T) {
+	for _, size := range []struct{ width, height uint64 }{{0, 4}, {4, 0}, {1 << 40, 1}, {1, 1 << 40}, {1 << 32, 1 << 32}, {1 << 13, 1 << 13}} {
+		rec := &Record{Version: Version, Width: size.width, Height: size.height, Limit: 11, Adds: 1}
+		if _, err := NewReplayer(rec); err == nil {
+			t.Errorf("%vx%v board: got no error", size.width, size.height)
+		}
+	}
+
+	rec := &Record{Version: Version, Width: 4, Height: 4, Limit: 11, Adds: 1, Initial: []game.Spawn{{Row: 4, Col: 0, Value: 1}}}
+	if _, err := NewReplayer(rec); err == nil {
+		t.Error("tile off the board: got no error")
+	}
+}
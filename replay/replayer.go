@@ -0,0 +1,155 @@
+package replay
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/dystopium/2048/game"
+)
+
+// MismatchError is returned when a replayed game doesn't place the tiles
+// the record says it should
+type MismatchError struct {
+	// Step is the index of the step that didn't match
+	Step     int
+	Expected []game.Spawn
+	Actual   []game.Spawn
+}
+
+func (e *MismatchError) Error() string {
+	return fmt.Sprintf("replay: step %v placed %v, recorded %v", e.Step, e.Actual, e.Expected)
+}
+
+// Replayer rebuilds a recorded game one move at a time
+type Replayer struct {
+	game.NopObserver
+
+	rec  *Record
+	g    *game.Game
+	next int
+
+	// spawns collects the tiles placed by the move in progress
+	spawns []game.Spawn
+}
+
+// NewReplayer starts a new game from rec
+func NewReplayer(rec *Record) (*Replayer, error) {
+	if rec.Version != Version {
+		return nil, fmt.Errorf("replay: unsupported version %v", rec.Version)
+	}
+
+	g, err := start(rec)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Replayer{rec: rec, g: g}
+	g.AddObserver(r)
+
+	return r, nil
+}
+
+// start creates the game rec begins with from its initial tiles
+func start(rec *Record) (*game.Game, error) {
+	if rec.Width == 0 || rec.Height == 0 {
+		return nil, game.ErrBadBoard
+	}
+
+	if rec.Width > maxSquares || rec.Height > maxSquares || rec.Width*rec.Height > maxSquares {
+		return nil, fmt.Errorf("replay: %vx%v board is too large", rec.Width, rec.Height)
+	}
+
+	board := make([][]uint64, rec.Height)
+	for i := range board {
+		board[i] = make([]uint64, rec.Width)
+	}
+
+	for _, sp := range rec.Initial {
+		if sp.Row >= rec.Height || sp.Col >= rec.Width {
+			return nil, fmt.Errorf("replay: initial tile %v is off the %vx%v board", sp, rec.Width, rec.Height)
+		}
+
+		board[sp.Row][sp.Col] = sp.Value
+	}
+
+	g, err := game.NewGameFromBoard(board, rec.Limit, rec.Adds, rec.Score, rec.Seed)
+	if err != nil {
+		return nil, err
+	}
+
+	g.SetRandState(rec.RNG)
+
+	return g, nil
+}
+
+// Spawned collects the tiles placed by the move being replayed
+func (r *Replayer) Spawned(sp game.Spawn) {
+	r.spawns = append(r.spawns, sp)
+}
+
+// Game returns the game being replayed
+// Moving it directly will stop the replay from matching.
+func (r *Replayer) Game() *game.Game {
+	return r.g
+}
+
+// Steps reports how many steps have been replayed so far
+func (r *Replayer) Steps() int {
+	return r.next
+}
+
+// Next replays the next step and checks it placed the recorded tiles
+// Returns io.EOF when every step has been replayed
+func (r *Replayer) Next() (Step, error) {
+	if r.next >= len(r.rec.Steps) {
+		return Step{}, io.EOF
+	}
+
+	step := r.rec.Steps[r.next]
+
+	r.spawns = nil
+	moves := r.g.TotalMoves()
+	r.g.Move(step.Dir)
+
+	if r.g.TotalMoves() == moves {
+		return step, fmt.Errorf("replay: step %v moving %v didn't change the board", r.next, step.Dir)
+	}
+
+	if !sameSpawns(r.spawns, step.Spawns) {
+		return step, &MismatchError{Step: r.next, Expected: step.Spawns, Actual: r.spawns}
+	}
+
+	r.next++
+
+	return step, nil
+}
+
+// Replay replays every step of rec and returns the finished game
+func Replay(rec *Record) (*game.Game, error) {
+	r, err := NewReplayer(rec)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		if _, err := r.Next(); err == io.EOF {
+			return r.Game(), nil
+		} else if err != nil {
+			return r.Game(), err
+		}
+	}
+}
+
+func sameSpawns(a, b []game.Spawn) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,63 @@
+package csv
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/dystopium/2048/reporters"
+)
+
+var header = []string{"player", "seed", "state", "score", "moves", "max_tile", "duration_ns", "board"}
+
+type reporter struct {
+	w       *csv.Writer
+	started bool
+}
+
+// New creates a reporter that writes a header and then a CSV row per game to w
+// Like reporters.Result, max_tile and board hold powers of 2
+// The board is written with rows separated by / and squares by spaces
+func New(w io.Writer) reporters.Reporter {
+	return &reporter{w: csv.NewWriter(w)}
+}
+
+func (r *reporter) Report(res reporters.Result) error {
+	if !r.started {
+		if err := r.w.Write(header); err != nil {
+			return err
+		}
+		r.started = true
+	}
+
+	return r.w.Write([]string{
+		res.Player,
+		strconv.FormatInt(res.Seed, 10),
+		res.State.String(),
+		strconv.FormatUint(res.Score, 10),
+		strconv.FormatUint(res.Moves, 10),
+		strconv.FormatUint(res.MaxTile, 10),
+		strconv.FormatInt(res.Duration.Nanoseconds(), 10),
+		formatBoard(res.Board),
+	})
+}
+
+func (r *reporter) Flush() error {
+	r.w.Flush()
+	return r.w.Error()
+}
+
+func formatBoard(board [][]uint64) string {
+	rows := make([]string, len(board))
+
+	for i, row := range board {
+		vals := make([]string, len(row))
+		for j, val := range row {
+			vals[j] = strconv.FormatUint(val, 10)
+		}
+		rows[i] = strings.Join(vals, " ")
+	}
+
+	return strings.Join(rows, "/")
+}
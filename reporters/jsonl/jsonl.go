@@ -0,0 +1,54 @@
+package jsonl
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+
+	"github.com/dystopium/2048/reporters"
+)
+
+// record is the JSON form of a reporters.Result
+type record struct {
+	Player     string     `json:"player,omitempty"`
+	Seed       int64      `json:"seed"`
+	State      string     `json:"state"`
+	Score      uint64     `json:"score"`
+	Moves      uint64     `json:"moves"`
+	MaxTile    uint64     `json:"max_tile"`
+	DurationNS int64      `json:"duration_ns"`
+	Board      [][]uint64 `json:"board"`
+}
+
+type reporter struct {
+	w   *bufio.Writer
+	enc *json.Encoder
+}
+
+// New creates a reporter that writes a JSON object per line to w
+// Like reporters.Result, max_tile and board hold powers of 2
+func New(w io.Writer) reporters.Reporter {
+	buf := bufio.NewWriter(w)
+
+	return &reporter{
+		w:   buf,
+		enc: json.NewEncoder(buf),
+	}
+}
+
+func (r *reporter) Report(res reporters.Result) error {
+	return r.enc.Encode(record{
+		Player:     res.Player,
+		Seed:       res.Seed,
+		State:      res.State.String(),
+		Score:      res.Score,
+		Moves:      res.Moves,
+		MaxTile:    res.MaxTile,
+		DurationNS: res.Duration.Nanoseconds(),
+		Board:      res.Board,
+	})
+}
+
+func (r *reporter) Flush() error {
+	return r.w.Flush()
+}
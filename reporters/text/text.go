@@ -0,0 +1,37 @@
+package text
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+
+	"github.com/dystopium/2048/reporters"
+)
+
+type reporter struct {
+	w     *bufio.Writer
+	games uint64
+}
+
+// New creates a reporter that writes a human readable line per game to w
+func New(w io.Writer) reporters.Reporter {
+	return &reporter{w: bufio.NewWriter(w)}
+}
+
+func (r *reporter) Report(res reporters.Result) error {
+	r.games++
+
+	if res.Player != "" {
+		if _, err := fmt.Fprintf(r.w, "%v ", res.Player); err != nil {
+			return err
+		}
+	}
+
+	_, err := fmt.Fprintf(r.w, "Game %v: %v\tScore: %v\tMoves: %v\tMax tile: %v\tSeed: %v\tTime: %v\n",
+		r.games, res.State, res.Score, res.Moves, uint64(1)<<res.MaxTile, res.Seed, res.Duration)
+	return err
+}
+
+func (r *reporter) Flush() error {
+	return r.w.Flush()
+}
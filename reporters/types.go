@@ -0,0 +1,68 @@
+package reporters
+
+import (
+	"time"
+
+	"github.com/dystopium/2048/game"
+)
+
+// Result is everything worth knowing about a finished game
+type Result struct {
+	// Player names the player when a runner plays more than one, otherwise empty
+	Player string
+
+	Seed  int64
+	State game.State
+	Score uint64
+	Moves uint64
+
+	// MaxTile is the power of 2 of the largest tile, like the values in Board
+	MaxTile uint64
+
+	// Duration is how long the player took to play the game
+	Duration time.Duration
+
+	// Board is the final board, as returned by game.Game.Board
+	Board [][]uint64
+}
+
+// NewResult collects the Result of a game that took d to play
+func NewResult(g *game.Game, d time.Duration) Result {
+	board := g.Board()
+
+	var maxTile uint64
+	for _, row := range board {
+		for _, val := range row {
+			if val > maxTile {
+				maxTile = val
+			}
+		}
+	}
+
+	return Result{
+		Seed:     g.Seed(),
+		State:    g.State(),
+		Score:    g.Score(),
+		Moves:    g.TotalMoves(),
+		MaxTile:  maxTile,
+		Duration: d,
+		Board:    board,
+	}
+}
+
+// Reporter receives the Result of every game a runner plays
+type Reporter interface {
+	// Report records a single result
+	Report(Result) error
+
+	// Flush makes sure everything reported so far has been written out
+	Flush() error
+}
+
+// Discard is a Reporter that throws every result away
+var Discard Reporter = discard{}
+
+type discard struct{}
+
+func (discard) Report(Result) error { return nil }
+func (discard) Flush() error        { return nil }
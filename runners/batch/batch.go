@@ -0,0 +1,116 @@
+package batch
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dystopium/2048/game"
+	"github.com/dystopium/2048/players"
+	"github.com/dystopium/2048/reporters"
+	"github.com/dystopium/2048/runners"
+	"github.com/dystopium/2048/stats"
+)
+
+// New creates a runner that plays exactly numGames games across all cores
+// and prints a statistical summary of how the player did
+func New(numGames uint64) runners.Runner {
+	return func(gg runners.GameGen, ag players.AgentGen, rep reporters.Reporter) error {
+		agents := make([]players.Agent, runners.Workers())
+		for i := range agents {
+			agents[i] = ag()
+		}
+
+		var all []reporters.Result
+		start := time.Now()
+
+		err := runners.Play(numGames,
+			func(uint64) *game.Game { return gg() },
+			func(worker int, _ uint64) players.Agent { return agents[worker] },
+			func(_ uint64, g *game.Game, elapsed time.Duration) error {
+				res := reporters.NewResult(g, elapsed)
+				all = append(all, res)
+
+				if len(all)%1000 == 0 {
+					fmt.Printf("Played %v of %v games in %v\n", len(all), numGames, time.Since(start))
+				}
+
+				return rep.Report(res)
+			})
+
+		if err != nil {
+			return err
+		}
+
+		summarize(all, time.Since(start))
+
+		return nil
+	}
+}
+
+func summarize(all []reporters.Result, elapsed time.Duration) {
+	if len(all) == 0 {
+		fmt.Println("\nNo games played")
+		return
+	}
+
+	var wins uint64
+	scores := make([]float64, len(all))
+	moves := make([]float64, len(all))
+
+	// reached[i] counts games where the max tile was at least 2^i
+	var reached []uint64
+
+	for i, res := range all {
+		if res.State == game.StateWon {
+			wins++
+		}
+
+		scores[i] = float64(res.Score)
+		moves[i] = float64(res.Moves)
+
+		for uint64(len(reached)) <= res.MaxTile {
+			reached = append(reached, 0)
+		}
+
+		for pow := uint64(1); pow <= res.MaxTile; pow++ {
+			reached[pow]++
+		}
+	}
+
+	n := uint64(len(all))
+	lo, hi := stats.Wilson(wins, n, stats.Z95)
+
+	fmt.Printf("\nPlayed %v games in %v (%.1f games/s)\n", n, elapsed, float64(n)/elapsed.Seconds())
+	fmt.Printf("\nWin rate: %.2f%% (95%% CI %.2f%% - %.2f%%)\n", 100*float64(wins)/float64(n), 100*lo, 100*hi)
+
+	fmt.Println()
+	printDistribution("Score", scores)
+	printDistribution("Moves", moves)
+
+	fmt.Println("\nMax tile reached:")
+
+	for pow := 1; pow < len(reached); pow++ {
+		if reached[pow] == n && pow+1 < len(reached) && reached[pow+1] == n {
+			// every game got here, not worth a line
+			continue
+		}
+
+		fmt.Printf("%10v  %6.2f%%  (%v games)\n", uint64(1)<<uint(pow), 100*float64(reached[pow])/float64(n), reached[pow])
+	}
+}
+
+func printDistribution(name string, xs []float64) {
+	sorted := stats.Sorted(xs)
+
+	fmt.Printf("%v: mean %.1f  sd %.1f  min %.0f  p10 %.0f  p25 %.0f  median %.0f  p75 %.0f  p90 %.0f  max %.0f\n",
+		name,
+		stats.Mean(xs),
+		stats.StdDev(xs),
+		sorted[0],
+		stats.Percentile(sorted, 10),
+		stats.Percentile(sorted, 25),
+		stats.Percentile(sorted, 50),
+		stats.Percentile(sorted, 75),
+		stats.Percentile(sorted, 90),
+		sorted[len(sorted)-1])
+}
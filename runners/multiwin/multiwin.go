@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/dystopium/2048/players"
+	"github.com/dystopium/2048/reporters"
 	"github.com/dystopium/2048/runners"
 
 	"github.com/dystopium/2048/game"
@@ -12,7 +13,8 @@ import (
 
 // New creates a new runner that plays until numWins games have been won
 func New(numWins uint64) runners.Runner {
-	return func(gg runners.GameGen, play players.Player) {
+	return func(gg runners.GameGen, ag players.AgentGen, rep reporters.Reporter) error {
+		a := ag()
 		var totalGamesToWin uint64
 		var totalMovesToWin uint64
 		var totalWinningScore uint64
@@ -25,7 +27,15 @@ func New(numWins uint64) runners.Runner {
 
 			for g.State() != game.StateWon {
 				g = gg()
-				play(g)
+				gameStart := time.Now()
+
+				if err := players.Run(g, a); err != nil {
+					return err
+				}
+
+				if err := rep.Report(reporters.NewResult(g, time.Since(gameStart))); err != nil {
+					return err
+				}
 
 				numGames++
 				numMoves += g.TotalMoves()
@@ -48,5 +58,7 @@ func New(numWins uint64) runners.Runner {
 		fmt.Printf("\nAverage %v games to win\n", float64(totalGamesToWin)/float64(numWins))
 		fmt.Printf("\nAverage %v moves in winning games\n", float64(totalMovesToWin)/float64(numWins))
 		fmt.Printf("\nAverage %v score in winning games\n", float64(totalWinningScore)/float64(numWins))
+
+		return nil
 	}
 }
@@ -2,46 +2,21 @@ package parallel
 
 import (
 	"fmt"
-	"runtime"
-	"sync"
+	"math"
 	"time"
 
 	"github.com/dystopium/2048/players"
+	"github.com/dystopium/2048/reporters"
 	"github.com/dystopium/2048/runners"
 
 	"github.com/dystopium/2048/game"
 )
 
 // Run runs games in as many cores as possible
-func Run(gg runners.GameGen, p players.Player) {
-
-	// times 2 for no good reason
-	numCores := runtime.NumCPU()
-
-	stop := make(chan *sync.WaitGroup)
-	results := make(chan *game.Game, numCores*2)
-	wg := &sync.WaitGroup{}
-
-	for i := 0; i < numCores; i++ {
-		wg.Add(1)
-
-		go func(gg runners.GameGen, play players.Player) {
-			for {
-				g := gg()
-				play(g)
-
-				// either send the result
-				// or accept the signal to stop looping
-				// This guarantees that if the results channel is full,
-				// it can still complete, it'll just throw out the result
-				select {
-				case results <- g:
-				case wg := <-stop:
-					wg.Done()
-					return
-				}
-			}
-		}(gg, p)
+func Run(gg runners.GameGen, ag players.AgentGen, rep reporters.Reporter) error {
+	agents := make([]players.Agent, runners.Workers())
+	for i := range agents {
+		agents[i] = ag()
 	}
 
 	g := &game.Game{}
@@ -49,25 +24,39 @@ func Run(gg runners.GameGen, p players.Player) {
 	var numMoves uint64
 	start := time.Now()
 
-	for g.State() != game.StateWon {
-		g = <-results
+	// play until a win, however many games that takes
+	err := runners.Play(math.MaxUint64,
+		func(uint64) *game.Game { return gg() },
+		func(worker int, _ uint64) players.Agent { return agents[worker] },
+		func(_ uint64, last *game.Game, elapsed time.Duration) error {
+			g = last
+
+			if err := rep.Report(reporters.NewResult(g, elapsed)); err != nil {
+				return err
+			}
 
-		numGames++
-		numMoves += g.TotalMoves()
+			numGames++
+			numMoves += g.TotalMoves()
 
-		if numGames%10000 == 0 {
-			elapsed := time.Since(start)
-			fmt.Printf("Played %v games in %v with an average %v moves to failure\n", numGames, elapsed, numMoves/numGames)
-		}
-	}
+			if numGames%10000 == 0 {
+				elapsed := time.Since(start)
+				fmt.Printf("Played %v games in %v with an average %v moves to failure\n", numGames, elapsed, numMoves/numGames)
+			}
 
-	// After the win, shut down the workers
-	for i := 0; i < numCores; i++ {
-		stop <- wg
+			if g.State() == game.StateWon {
+				return runners.ErrStop
+			}
+
+			return nil
+		})
+
+	if err != nil {
+		return err
 	}
-	wg.Wait()
 
 	fmt.Printf("\nWinning took %v games\n", numGames)
-	fmt.Printf("\nScore: %v\tMoves: %v\n\n", g.Score(), g.TotalMoves())
+	fmt.Printf("\nScore: %v\tMoves: %v\tSeed: %v\n\n", g.Score(), g.TotalMoves(), g.Seed())
 	fmt.Println(g)
+
+	return nil
 }
@@ -0,0 +1,100 @@
+package runners
+
+import (
+	"errors"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/dystopium/2048/game"
+	"github.com/dystopium/2048/players"
+)
+
+// ErrStop can be returned by the finished func given to Play to stop playing
+// without an error
+var ErrStop = errors.New("runners: stop playing")
+
+// Workers is how many games Play plays at once, one for each core
+func Workers() int {
+	return runtime.NumCPU()
+}
+
+// played is a finished game from one of Play's workers
+type played struct {
+	i       uint64
+	g       *game.Game
+	elapsed time.Duration
+	err     error
+}
+
+// Play plays count games across Workers() goroutines, calling finished
+// from this goroutine with each game as it ends and how long it took
+// Game i is made by newGame(i) and played by agent(worker, i), where worker
+// is which of the goroutines from 0 up is playing it, so agents can be
+// kept for each one. The first error from a game or from finished stops
+// any more games starting, and is returned once the ones being played end.
+func Play(count uint64, newGame func(i uint64) *game.Game, agent func(worker int, i uint64) players.Agent, finished func(i uint64, g *game.Game, elapsed time.Duration) error) error {
+	numWorkers := Workers()
+
+	jobs := make(chan uint64)
+	quit := make(chan struct{})
+	results := make(chan played, numWorkers*2)
+	wg := &sync.WaitGroup{}
+
+	for w := 0; w < numWorkers; w++ {
+		wg.Add(1)
+
+		go func(w int) {
+			defer wg.Done()
+
+			for i := range jobs {
+				g := newGame(i)
+				start := time.Now()
+				err := players.Run(g, agent(w, i))
+
+				results <- played{i: i, g: g, elapsed: time.Since(start), err: err}
+			}
+		}(w)
+	}
+
+	go func() {
+		defer close(jobs)
+
+		for i := uint64(0); i < count; i++ {
+			select {
+			case jobs <- i:
+			case <-quit:
+				return
+			}
+		}
+	}()
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	var firstErr error
+
+	// keep draining after an error so the workers can finish
+	for p := range results {
+		if firstErr != nil {
+			continue
+		}
+
+		firstErr = p.err
+		if firstErr == nil {
+			firstErr = finished(p.i, p.g, p.elapsed)
+		}
+
+		if firstErr != nil {
+			close(quit)
+		}
+	}
+
+	if firstErr == ErrStop {
+		return nil
+	}
+
+	return firstErr
+}
@@ -2,17 +2,27 @@ package single
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/dystopium/2048/game"
 
 	"github.com/dystopium/2048/players"
+	"github.com/dystopium/2048/reporters"
 	"github.com/dystopium/2048/runners"
 )
 
 // Run will run a single game until it wins or loses
-func Run(gg runners.GameGen, play players.Player) {
+func Run(gg runners.GameGen, ag players.AgentGen, rep reporters.Reporter) error {
 	g := gg()
-	play(g)
+	start := time.Now()
+
+	if err := players.Run(g, ag()); err != nil {
+		return err
+	}
+
+	if err := rep.Report(reporters.NewResult(g, time.Since(start))); err != nil {
+		return err
+	}
 
 	switch g.State() {
 	case game.StateWon:
@@ -22,6 +32,8 @@ func Run(gg runners.GameGen, play players.Player) {
 		fmt.Println("\nYOU LOST!")
 	}
 
-	fmt.Printf("\nScore: %v\tMoves: %v\n\n", g.Score(), g.TotalMoves())
+	fmt.Printf("\nScore: %v\tMoves: %v\tSeed: %v\n\n", g.Score(), g.TotalMoves(), g.Seed())
 	fmt.Println(g)
+
+	return nil
 }
@@ -0,0 +1,132 @@
+package tournament
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dystopium/2048/game"
+	"github.com/dystopium/2048/players"
+	"github.com/dystopium/2048/reporters"
+	"github.com/dystopium/2048/runners"
+	"github.com/dystopium/2048/stats"
+)
+
+// Entry is a named player taking part in a tournament
+type Entry struct {
+	Name  string
+	Agent players.AgentGen
+}
+
+// Run plays numGames games with every entry, across all cores
+// Game i uses seed firstSeed+i for every entry, so they all get the same
+// luck with new tiles. Prints how each pair of entries compares.
+func Run(sgg runners.SeededGameGen, entries []Entry, firstSeed int64, numGames uint64, rep reporters.Reporter) error {
+	for _, e := range entries {
+		if e.Agent == nil {
+			return fmt.Errorf("tournament: no player for entry %q", e.Name)
+		}
+	}
+
+	// job i is game i/len(entries) for entry i%len(entries)
+	numEntries := uint64(len(entries))
+
+	// each worker has its own agent for every entry, made when first needed
+	agents := make([][]players.Agent, runners.Workers())
+	for i := range agents {
+		agents[i] = make([]players.Agent, len(entries))
+	}
+
+	// all[entry][game]
+	all := make([][]reporters.Result, len(entries))
+	for i := range all {
+		all[i] = make([]reporters.Result, numGames)
+	}
+
+	var count uint64
+	start := time.Now()
+
+	err := runners.Play(numGames*numEntries,
+		func(i uint64) *game.Game { return sgg(firstSeed + int64(i/numEntries)) },
+		func(worker int, i uint64) players.Agent {
+			e := i % numEntries
+			if agents[worker][e] == nil {
+				agents[worker][e] = entries[e].Agent()
+			}
+
+			return agents[worker][e]
+		},
+		func(i uint64, g *game.Game, elapsed time.Duration) error {
+			res := reporters.NewResult(g, elapsed)
+			res.Player = entries[i%numEntries].Name
+
+			all[i%numEntries][i/numEntries] = res
+			count++
+
+			if count%1000 == 0 {
+				fmt.Printf("Played %v of %v games in %v\n", count, numGames*numEntries, time.Since(start))
+			}
+
+			return rep.Report(res)
+		})
+
+	if err != nil {
+		return err
+	}
+
+	summarize(entries, all)
+
+	return nil
+}
+
+func summarize(entries []Entry, all [][]reporters.Result) {
+	fmt.Println()
+
+	for i, e := range entries {
+		var wins uint64
+		scores := make([]float64, len(all[i]))
+
+		for j, res := range all[i] {
+			scores[j] = float64(res.Score)
+			if res.State == game.StateWon {
+				wins++
+			}
+		}
+
+		fmt.Printf("%v: mean score %.1f  win rate %.2f%%\n", e.Name, stats.Mean(scores), 100*float64(wins)/float64(len(scores)))
+	}
+
+	for a := 0; a < len(entries); a++ {
+		for b := a + 1; b < len(entries); b++ {
+			compare(entries[a].Name, entries[b].Name, all[a], all[b])
+		}
+	}
+}
+
+// compare prints the paired differences between two entries, a minus b
+func compare(nameA, nameB string, a, b []reporters.Result) {
+	diffs := make([]float64, len(a))
+
+	// games only one of them won
+	var onlyA, onlyB uint64
+
+	for i := range a {
+		diffs[i] = float64(a[i].Score) - float64(b[i].Score)
+
+		wonA := a[i].State == game.StateWon
+		wonB := b[i].State == game.StateWon
+
+		if wonA && !wonB {
+			onlyA++
+		} else if wonB && !wonA {
+			onlyB++
+		}
+	}
+
+	n := float64(len(a))
+	mean, lo, hi, p := stats.Paired(diffs, stats.Z95)
+
+	fmt.Printf("\n%v vs %v over %v paired games\n", nameA, nameB, len(a))
+	fmt.Printf("  Score difference: %.1f (95%% CI %.1f to %.1f), p = %.4g\n", mean, lo, hi, p)
+	fmt.Printf("  Win rate difference: %.2f%% (%v won only by %v, %v won only by %v), p = %.4g\n",
+		100*(float64(onlyA)-float64(onlyB))/n, onlyA, nameA, onlyB, nameB, stats.McNemar(onlyA, onlyB))
+}
@@ -0,0 +1,261 @@
+package train
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/dystopium/2048/game"
+	"github.com/dystopium/2048/players"
+	"github.com/dystopium/2048/runners"
+)
+
+// Learner is a player that gets better as it plays
+type Learner struct {
+	// Learn creates agents that learn from every game they play
+	Learn players.AgentGen
+
+	// Play creates agents that play what has been learned, without learning
+	Play players.AgentGen
+
+	// Save writes what has been learned so far to the file name
+	Save func(name string) error
+}
+
+// Config says how long to train for and how to keep track of it
+// Anything set to 0 is skipped.
+type Config struct {
+	// Episodes is how many training games to play in total, counting the
+	// ones played before resuming
+	Episodes uint64
+
+	// Done is how many were played before resuming
+	Done uint64
+
+	// LogEvery is how many training games each point of the learning
+	// curve averages over
+	LogEvery uint64
+
+	// EvalEvery is how many training games to play between evaluations
+	// Every evaluation plays EvalGames games without learning, using seeds
+	// from EvalSeed on, so they're all played on the same games. Training
+	// games shouldn't use these seeds, or the evaluation isn't held out.
+	EvalEvery uint64
+	EvalGames uint64
+	EvalSeed  int64
+
+	// CheckpointEvery is how many training games to play between saving
+	// to Checkpoint. It's also saved at the end.
+	CheckpointEvery uint64
+	Checkpoint      string
+
+	// Log gets the learning curve as CSV, a row for each LogEvery training
+	// games and each evaluation
+	Log io.Writer
+}
+
+var header = []string{"episodes", "phase", "games", "mean_score", "win_rate", "elapsed_s"}
+
+// progress is saved alongside each checkpoint so training can resume
+type progress struct {
+	Episodes uint64 `json:"episodes"`
+}
+
+// progressFile is where the progress for checkpoint is saved
+func progressFile(checkpoint string) string {
+	return checkpoint + ".progress"
+}
+
+// Resume finds how many training games were played before checkpoint was saved
+func Resume(checkpoint string) (uint64, error) {
+	data, err := os.ReadFile(progressFile(checkpoint))
+	if err != nil {
+		return 0, err
+	}
+
+	var p progress
+	if err := json.Unmarshal(data, &p); err != nil {
+		return 0, err
+	}
+
+	return p.Episodes, nil
+}
+
+// tally adds up the games played since it was last reset
+type tally struct {
+	games uint64
+	score uint64
+	wins  uint64
+}
+
+func (t *tally) add(g *game.Game) {
+	t.games++
+	t.score += g.Score()
+	if g.State() == game.StateWon {
+		t.wins++
+	}
+}
+
+func (t *tally) mean() float64 {
+	return float64(t.score) / float64(t.games)
+}
+
+func (t *tally) winRate() float64 {
+	return float64(t.wins) / float64(t.games)
+}
+
+// Run trains l on games from gg across all cores
+// Evaluation games come from sgg.
+func Run(gg runners.GameGen, sgg runners.SeededGameGen, l Learner, cfg Config) error {
+	// each core keeps its agents for the whole run
+	learners := make([]players.Agent, runners.Workers())
+	evaluators := make([]players.Agent, runners.Workers())
+	for i := range learners {
+		learners[i] = l.Learn()
+		evaluators[i] = l.Play()
+	}
+
+	var log *csv.Writer
+	if cfg.Log != nil {
+		log = csv.NewWriter(cfg.Log)
+
+		// a resumed run carries on the same log
+		if cfg.Done == 0 {
+			log.Write(header)
+		}
+	}
+
+	start := time.Now()
+
+	record := func(done uint64, phase string, t *tally) error {
+		if log == nil {
+			return nil
+		}
+
+		log.Write([]string{
+			strconv.FormatUint(done, 10),
+			phase,
+			strconv.FormatUint(t.games, 10),
+			strconv.FormatFloat(t.mean(), 'f', 1, 64),
+			strconv.FormatFloat(t.winRate(), 'f', 4, 64),
+			strconv.FormatFloat(time.Since(start).Seconds(), 'f', 1, 64),
+		})
+		log.Flush()
+
+		return log.Error()
+	}
+
+	done := cfg.Done
+	var block tally
+
+	for done < cfg.Episodes {
+		// play until the next evaluation, checkpoint or the end
+		stop := cfg.Episodes
+		if next := nextMultiple(done, cfg.EvalEvery); next < stop {
+			stop = next
+		}
+		if next := nextMultiple(done, cfg.CheckpointEvery); next < stop {
+			stop = next
+		}
+
+		var logErr error
+
+		err := playAll(learners, func(uint64) *game.Game { return gg() }, stop-done, func(g *game.Game) {
+			block.add(g)
+			done++
+
+			if cfg.LogEvery == 0 || block.games < cfg.LogEvery {
+				return
+			}
+
+			fmt.Printf("Episodes %v: mean score %.1f  win rate %.2f%%  (%v)\n", done, block.mean(), 100*block.winRate(), time.Since(start))
+
+			if err := record(done, "train", &block); err != nil && logErr == nil {
+				logErr = err
+			}
+			block = tally{}
+		})
+
+		if err != nil {
+			return err
+		}
+
+		if logErr != nil {
+			return logErr
+		}
+
+		if cfg.EvalEvery > 0 && cfg.EvalGames > 0 && (done%cfg.EvalEvery == 0 || done == cfg.Episodes) {
+			var eval tally
+
+			err := playAll(evaluators, func(i uint64) *game.Game { return sgg(cfg.EvalSeed + int64(i)) }, cfg.EvalGames, eval.add)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Evaluation after %v episodes: mean score %.1f  win rate %.2f%% over %v games\n", done, eval.mean(), 100*eval.winRate(), eval.games)
+
+			if err := record(done, "eval", &eval); err != nil {
+				return err
+			}
+		}
+
+		if cfg.Checkpoint != "" && (done == cfg.Episodes || cfg.CheckpointEvery > 0 && done%cfg.CheckpointEvery == 0) {
+			if err := checkpoint(l, cfg.Checkpoint, done); err != nil {
+				return err
+			}
+
+			fmt.Printf("Saved %v after %v episodes\n", cfg.Checkpoint, done)
+		}
+	}
+
+	return nil
+}
+
+// nextMultiple finds the first multiple of every after done
+// Returns the largest number possible if every is 0
+func nextMultiple(done, every uint64) uint64 {
+	if every == 0 {
+		return ^uint64(0)
+	}
+
+	return (done/every + 1) * every
+}
+
+// playAll plays count games, each agent playing on its own core, calling
+// finished with each game from this goroutine as it ends
+// Game i of the count comes from newGame(i).
+func playAll(agents []players.Agent, newGame func(i uint64) *game.Game, count uint64, finished func(*game.Game)) error {
+	return runners.Play(count, newGame,
+		func(worker int, _ uint64) players.Agent { return agents[worker] },
+		func(_ uint64, g *game.Game, _ time.Duration) error {
+			finished(g)
+			return nil
+		})
+}
+
+// checkpoint saves l and the progress, writing each to a temporary file
+// first so a crash part way through leaves the last checkpoint intact
+func checkpoint(l Learner, name string, done uint64) error {
+	if err := l.Save(name + ".tmp"); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(progress{Episodes: done})
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(progressFile(name)+".tmp", data, 0644); err != nil {
+		return err
+	}
+
+	if err := os.Rename(name+".tmp", name); err != nil {
+		return err
+	}
+
+	return os.Rename(progressFile(name)+".tmp", progressFile(name))
+}
@@ -0,0 +1,196 @@
+package tune
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/dystopium/2048/game"
+	"github.com/dystopium/2048/heuristics"
+	"github.com/dystopium/2048/players"
+	"github.com/dystopium/2048/runners"
+)
+
+// Player creates agents that play with the heuristic weighted by w
+type Player func(w heuristics.Weights) players.AgentGen
+
+// Config says how to search for weights
+type Config struct {
+	// Generations is how many times the population is bred
+	Generations uint64
+
+	// Population is how many sets of weights are tried each generation
+	Population uint64
+
+	// Games is how many games each set of weights plays, using seeds from
+	// FirstSeed on, so every set plays the same games
+	Games     uint64
+	FirstSeed int64
+
+	// Sigma is how far mutations move each weight at first, relative to
+	// the size of the starting weight or 1, whichever is larger
+	// It shrinks by Decay each generation.
+	Sigma float64
+	Decay float64
+
+	// Seed seeds the random choices of the search itself
+	Seed int64
+
+	// Out is a file to write the best weights to after every generation
+	Out string
+}
+
+// ErrNoGenerations is returned when asked to search for no generations,
+// which would never try any weights
+var ErrNoGenerations = errors.New("tune: need at least one generation")
+
+// candidate is a set of weights and how well it did
+type candidate struct {
+	vals    []float64
+	fitness float64
+}
+
+// Run searches for the weights that get the best mean score, starting
+// around start, and returns the best found
+// The best quarter of each generation carries on to the next unchanged,
+// and the rest are bred from them by crossover and mutation.
+func Run(sgg runners.SeededGameGen, p Player, start heuristics.Weights, cfg Config) (heuristics.Weights, error) {
+	if cfg.Generations == 0 {
+		return heuristics.Weights{}, ErrNoGenerations
+	}
+
+	if cfg.Population < 2 {
+		cfg.Population = 2
+	}
+
+	if cfg.Games == 0 {
+		cfg.Games = 1
+	}
+
+	rnd := rand.New(rand.NewSource(cfg.Seed))
+
+	base := start.Values()
+
+	// how much each weight is mutated by, before sigma
+	scale := make([]float64, len(base))
+	for i, v := range base {
+		scale[i] = math.Max(math.Abs(v), 1)
+	}
+
+	sigma := cfg.Sigma
+
+	mutate := func(vals []float64) []float64 {
+		ret := make([]float64, len(vals))
+		for i, v := range vals {
+			ret[i] = v + rnd.NormFloat64()*sigma*scale[i]
+		}
+
+		return ret
+	}
+
+	// the starting weights are always in the first generation
+	pop := []candidate{{vals: base}}
+	for uint64(len(pop)) < cfg.Population {
+		pop = append(pop, candidate{vals: mutate(base)})
+	}
+
+	numElite := int(cfg.Population / 4)
+	if numElite < 1 {
+		numElite = 1
+	}
+
+	// elites keep their fitness, only new candidates need playing
+	scored := 0
+	best := candidate{fitness: math.Inf(-1)}
+	begin := time.Now()
+
+	for gen := uint64(0); gen < cfg.Generations; gen++ {
+		if err := evaluate(sgg, p, pop[scored:], cfg); err != nil {
+			return heuristics.Weights{}, err
+		}
+
+		sort.SliceStable(pop, func(a, b int) bool {
+			return pop[a].fitness > pop[b].fitness
+		})
+
+		var total float64
+		for _, c := range pop {
+			total += c.fitness
+		}
+
+		if pop[0].fitness > best.fitness {
+			best = pop[0]
+		}
+
+		w := heuristics.FromValues(best.vals)
+		fmt.Printf("Generation %v: best mean score %.1f  population mean %.1f  (%v)\n", gen+1, pop[0].fitness, total/float64(len(pop)), time.Since(begin))
+		fmt.Printf("  best so far %.1f: %v\n", best.fitness, &w)
+
+		if cfg.Out != "" {
+			if err := heuristics.SaveWeights(cfg.Out, w); err != nil {
+				return heuristics.Weights{}, err
+			}
+		}
+
+		// breed the next generation from the elite
+		next := append([]candidate(nil), pop[:numElite]...)
+		for uint64(len(next)) < cfg.Population {
+			a := pop[rnd.Intn(numElite)].vals
+			b := pop[rnd.Intn(numElite)].vals
+
+			child := make([]float64, len(a))
+			for i := range child {
+				if rnd.Intn(2) == 0 {
+					child[i] = a[i]
+				} else {
+					child[i] = b[i]
+				}
+			}
+
+			next = append(next, candidate{vals: mutate(child)})
+		}
+
+		pop = next
+		scored = numElite
+
+		if cfg.Decay > 0 {
+			sigma *= cfg.Decay
+		}
+	}
+
+	return heuristics.FromValues(best.vals), nil
+}
+
+// evaluate plays every candidate's games across all cores, setting each
+// one's fitness to its mean score
+func evaluate(sgg runners.SeededGameGen, p Player, cands []candidate, cfg Config) error {
+	gens := make([]players.AgentGen, len(cands))
+	for i, c := range cands {
+		gens[i] = p(heuristics.FromValues(c.vals))
+	}
+
+	// job i is game i/len(cands) for candidate i%len(cands)
+	numCands := uint64(len(cands))
+	totals := make([]uint64, len(cands))
+
+	err := runners.Play(cfg.Games*numCands,
+		func(i uint64) *game.Game { return sgg(cfg.FirstSeed + int64(i/numCands)) },
+		func(_ int, i uint64) players.Agent { return gens[i%numCands]() },
+		func(i uint64, g *game.Game, _ time.Duration) error {
+			totals[i%numCands] += g.Score()
+			return nil
+		})
+
+	if err != nil {
+		return err
+	}
+
+	for i := range cands {
+		cands[i].fitness = float64(totals[i]) / float64(cfg.Games)
+	}
+
+	return nil
+}
@@ -3,11 +3,18 @@ package runners
 import (
 	"github.com/dystopium/2048/game"
 	"github.com/dystopium/2048/players"
+	"github.com/dystopium/2048/reporters"
 )
 
 // GameGen creates a new game each time it is called
 // Ideally it's closed over the config needed
 type GameGen func() *game.Game
 
+// SeededGameGen creates a new game from the given seed each time it is called
+// The same seed must always give the same game
+type SeededGameGen func(seed int64) *game.Game
+
 // Runner is a thing that will run games however it sees fit
-type Runner func(GameGen, players.Player)
+// It drives the agents itself, creating one for each game it plays at a time.
+// The result of every game played is sent to the reporter
+type Runner func(GameGen, players.AgentGen, reporters.Reporter) error
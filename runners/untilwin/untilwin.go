@@ -5,13 +5,15 @@ import (
 	"time"
 
 	"github.com/dystopium/2048/players"
+	"github.com/dystopium/2048/reporters"
 	"github.com/dystopium/2048/runners"
 
 	"github.com/dystopium/2048/game"
 )
 
 // Run plays the game until a game is won
-func Run(gg runners.GameGen, play players.Player) {
+func Run(gg runners.GameGen, ag players.AgentGen, rep reporters.Reporter) error {
+	a := ag()
 
 	g := &game.Game{}
 	var numGames uint64
@@ -20,7 +22,15 @@ func Run(gg runners.GameGen, play players.Player) {
 
 	for g.State() != game.StateWon {
 		g = gg()
-		play(g)
+		gameStart := time.Now()
+
+		if err := players.Run(g, a); err != nil {
+			return err
+		}
+
+		if err := rep.Report(reporters.NewResult(g, time.Since(gameStart))); err != nil {
+			return err
+		}
 
 		numGames++
 		numMoves += g.TotalMoves()
@@ -32,6 +42,8 @@ func Run(gg runners.GameGen, play players.Player) {
 	}
 
 	fmt.Printf("\nWinning took %v games\n", numGames)
-	fmt.Printf("\nScore: %v\tMoves: %v\n\n", g.Score(), g.TotalMoves())
+	fmt.Printf("\nScore: %v\tMoves: %v\tSeed: %v\n\n", g.Score(), g.TotalMoves(), g.Seed())
 	fmt.Println(g)
+
+	return nil
 }
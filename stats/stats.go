@@ -0,0 +1,133 @@
+package stats
+
+import (
+	"math"
+	"sort"
+)
+
+// Z95 is the z score for a two sided 95% confidence interval
+const Z95 = 1.959963984540054
+
+// Mean is the arithmetic mean of xs, or 0 if there are none
+func Mean(xs []float64) float64 {
+	if len(xs) == 0 {
+		return 0
+	}
+
+	var total float64
+	for _, x := range xs {
+		total += x
+	}
+
+	return total / float64(len(xs))
+}
+
+// StdDev is the sample standard deviation of xs, or 0 if there are fewer than 2
+func StdDev(xs []float64) float64 {
+	if len(xs) < 2 {
+		return 0
+	}
+
+	mean := Mean(xs)
+
+	var total float64
+	for _, x := range xs {
+		total += (x - mean) * (x - mean)
+	}
+
+	return math.Sqrt(total / float64(len(xs)-1))
+}
+
+// Sorted returns a sorted copy of xs, ready for Percentile
+func Sorted(xs []float64) []float64 {
+	ret := make([]float64, len(xs))
+	copy(ret, xs)
+	sort.Float64s(ret)
+
+	return ret
+}
+
+// Percentile finds the pth percentile of sorted, for p from 0 to 100
+// Values between two samples are interpolated
+func Percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	pos := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(pos))
+	upper := int(math.Ceil(pos))
+
+	if lower < 0 {
+		return sorted[0]
+	}
+
+	if upper >= len(sorted) {
+		return sorted[len(sorted)-1]
+	}
+
+	frac := pos - float64(lower)
+
+	return sorted[lower] + frac*(sorted[upper]-sorted[lower])
+}
+
+// Wilson is the Wilson score interval for a proportion of successes out of n
+// z sets the confidence, use Z95 for 95%
+func Wilson(successes, n uint64, z float64) (float64, float64) {
+	if n == 0 {
+		return 0, 1
+	}
+
+	total := float64(n)
+	p := float64(successes) / total
+	z2 := z * z
+
+	centre := (p + z2/(2*total)) / (1 + z2/total)
+	spread := z / (1 + z2/total) * math.Sqrt(p*(1-p)/total+z2/(4*total*total))
+
+	return math.Max(0, centre-spread), math.Min(1, centre+spread)
+}
+
+// NormalPValue is the two sided p-value of a standard normal z score
+func NormalPValue(z float64) float64 {
+	return math.Erfc(math.Abs(z) / math.Sqrt2)
+}
+
+// Paired tests whether the mean of paired differences is different from 0
+// Returns the mean difference, a confidence interval for it using z, and the
+// two sided p-value. Uses the normal approximation, so wants at least 30 pairs.
+func Paired(diffs []float64, z float64) (mean, lo, hi, p float64) {
+	mean = Mean(diffs)
+
+	if len(diffs) < 2 {
+		return mean, math.Inf(-1), math.Inf(1), 1
+	}
+
+	stderr := StdDev(diffs) / math.Sqrt(float64(len(diffs)))
+	if stderr == 0 {
+		if mean == 0 {
+			return mean, mean, mean, 1
+		}
+		return mean, mean, mean, 0
+	}
+
+	return mean, mean - z*stderr, mean + z*stderr, NormalPValue(mean / stderr)
+}
+
+// McNemar tests whether two paired proportions differ
+// onlyA and onlyB count the pairs where only the first or only the second
+// succeeded. Returns the two sided p-value, using a continuity correction.
+func McNemar(onlyA, onlyB uint64) float64 {
+	if onlyA+onlyB == 0 {
+		return 1
+	}
+
+	diff := math.Abs(float64(onlyA)-float64(onlyB)) - 1
+	if diff < 0 {
+		diff = 0
+	}
+
+	chi2 := diff * diff / float64(onlyA+onlyB)
+
+	return NormalPValue(math.Sqrt(chi2))
+}